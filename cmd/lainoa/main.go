@@ -1,35 +1,319 @@
 package main
 
 import (
+	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"os/user"
+	"regexp"
+	"strings"
 
+	"github.com/uesteibar/lainoa/pkg/ast"
+	"github.com/uesteibar/lainoa/pkg/coverage"
+	"github.com/uesteibar/lainoa/pkg/dap"
+	"github.com/uesteibar/lainoa/pkg/debugger"
+	"github.com/uesteibar/lainoa/pkg/evaluator"
+	"github.com/uesteibar/lainoa/pkg/lexer"
+	"github.com/uesteibar/lainoa/pkg/lint"
+	"github.com/uesteibar/lainoa/pkg/parser"
+	"github.com/uesteibar/lainoa/pkg/profiler"
 	"github.com/uesteibar/lainoa/pkg/repl"
 	"github.com/uesteibar/lainoa/pkg/runner"
+	"github.com/uesteibar/lainoa/pkg/tester"
+	"github.com/uesteibar/lainoa/pkg/token"
 )
 
 func printHelp() {
 	fmt.Println(`
 The following commands are available:
 
-	run		run a file
-	repl	start the lainoa REPL (interactive console)
-	help	print this nice little help`)
+	run		run a file, passing it any arguments after the file
+	eval		evaluate some code and print its value
+	test		run the tests in a directory (default: current directory)
+	debug		debug a file, stopping before its first line
+	dap		serve the Debug Adapter Protocol over stdin and stdout, for editors
+	tokens		print the tokens in a file
+	ast		print the syntax tree of a file
+	lint		check files for code that's likely a mistake
+	repl		start the lainoa REPL (interactive console)
+	help		print this nice little help, or the one of a command
+
+Run lainoa help <command> to see the options of a command.
+
+lainoa path/to/file.ln [args...] runs a file too, so scripts can start with
+a #!/usr/bin/env lainoa line.`)
+}
+
+// newFlagSet creates the flags of a command, whose --help shows how to use
+// it along with every option.
+func newFlagSet(name string, usage string, description string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	flags.Usage = func() {
+		out := flags.Output()
+		fmt.Fprintf(out, "Usage: lainoa %s %s\n\n%s\n", name, usage, description)
+
+		hasFlags := false
+		flags.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Fprintln(out, "\nOptions:")
+			flags.PrintDefaults()
+		}
+	}
+
+	return flags
+}
+
+type filesystemFlags struct {
+	roots    *string
+	write    *bool
+	disabled *bool
+}
+
+func addFilesystemFlags(flags *flag.FlagSet) *filesystemFlags {
+	return &filesystemFlags{
+		roots:    flags.String("fs", ".", "comma separated directories scripts can access"),
+		write:    flags.Bool("fs-write", false, "allow writing and removing files"),
+		disabled: flags.Bool("no-fs", false, "disable filesystem access completely"),
+	}
+}
+
+// configure sets up the evaluator with the parsed filesystem options.
+func (f *filesystemFlags) configure() {
+	fs := evaluator.Filesystem{ReadOnly: !*f.write}
+	if !*f.disabled {
+		fs.Roots = strings.Split(*f.roots, ",")
+	}
+	if err := evaluator.SetFilesystem(fs); err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid --fs directory:", err)
+		os.Exit(1)
+	}
+}
+
+type coverageFlags struct {
+	profile *string
+	report  *string
 }
 
-func run() {
-	if len(os.Args) < 3 {
-		fmt.Println("You need to tell me what file to run:")
-		fmt.Println("\tlainoa run path/to/file.ln")
+func addCoverageFlags(flags *flag.FlagSet) *coverageFlags {
+	return &coverageFlags{
+		profile: flags.String("coverprofile", "", "write a coverage profile to this file"),
+		report:  flags.String("coverreport", "", "write the source annotated with coverage to this file"),
+	}
+}
+
+// start turns coverage on when it has been asked for.
+func (f *coverageFlags) start() *coverage.Profile {
+	if *f.profile == "" && *f.report == "" {
+		return nil
+	}
+
+	profile := coverage.New()
+	evaluator.SetCoverage(profile)
+	return profile
+}
+
+// finish writes the requested coverage files and prints a summary to out.
+func (f *coverageFlags) finish(profile *coverage.Profile, out io.Writer) {
+	if profile == nil {
 		return
 	}
 
-	filepath := os.Args[2]
-	runner.Start(filepath)
+	if *f.profile != "" {
+		writeCoverage(*f.profile, profile.WriteProfile)
+	}
+	if *f.report != "" {
+		writeCoverage(*f.report, profile.WriteReport)
+	}
+	fmt.Fprintln(out, profile.Summary())
+}
+
+func writeCoverage(path string, write func(io.Writer) error) {
+	file, err := os.Create(path)
+	if err == nil {
+		err = write(file)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing coverage:", err)
+		os.Exit(1)
+	}
+}
+
+type profileFlags struct {
+	path *string
+	top  *int
+}
+
+func addProfileFlags(flags *flag.FlagSet) *profileFlags {
+	return &profileFlags{
+		path: flags.String("profile", "", "write a pprof profile of the function calls to this file"),
+		top:  flags.Int("profile-top", 20, "how many functions to show in the profile summary, 0 for all"),
+	}
+}
+
+// start turns profiling on when it has been asked for.
+func (f *profileFlags) start() *profiler.Profiler {
+	if *f.path == "" {
+		return nil
+	}
+
+	p := profiler.New()
+	evaluator.SetProfiler(p)
+	return p
+}
+
+// finish writes the pprof profile and prints the top functions to stderr.
+func (f *profileFlags) finish(p *profiler.Profiler) {
+	if p == nil {
+		return
+	}
+	p.Stop()
+
+	file, err := os.Create(*f.path)
+	if err == nil {
+		err = p.WritePprof(file)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing profile:", err)
+		os.Exit(1)
+	}
+
+	fmt.Fprintln(os.Stderr)
+	p.WriteTop(os.Stderr, *f.top)
+}
+
+func run(arguments []string) {
+	flags := newFlagSet("run", "[options] path/to/file.ln [args...]",
+		"Runs a file, or the program in stdin when the path is -, passing it the\narguments after it.")
+	fs := addFilesystemFlags(flags)
+	cover := addCoverageFlags(flags)
+	prof := addProfileFlags(flags)
+	optimize := flags.Bool("optimize", false, "fold constant expressions and remove dead code before running")
+	flags.Parse(arguments)
+	fs.configure()
+	runner.SetOptimize(*optimize)
+
+	args := flags.Args()
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, "You need to tell me what file to run:")
+		fmt.Fprintln(os.Stderr, "\tlainoa run path/to/file.ln [args...]")
+		os.Exit(1)
+	}
+
+	profile := cover.start()
+	p := prof.start()
+	filepath := args[0]
+	status := runner.Start(filepath, args[1:])
+	prof.finish(p)
+	cover.finish(profile, os.Stderr)
+
+	os.Exit(status)
+}
+
+func eval(arguments []string) {
+	flags := newFlagSet("eval", "[options] code [args...]",
+		"Evaluates code and prints the value it evaluates to, passing it the arguments\nafter it.")
+	fs := addFilesystemFlags(flags)
+	optimize := flags.Bool("optimize", false, "fold constant expressions and remove dead code before running")
+	flags.Parse(arguments)
+	fs.configure()
+	runner.SetOptimize(*optimize)
+
+	args := flags.Args()
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, "You need to tell me what code to evaluate:")
+		fmt.Fprintln(os.Stderr, "\tlainoa eval '1 + 2' [args...]")
+		os.Exit(1)
+	}
+
+	os.Exit(runner.Eval(args[0], args[1:]))
+}
+
+func debug(arguments []string) {
+	flags := newFlagSet("debug", "[options] path/to/file.ln [args...]",
+		"Debugs a file, stopping before its first line. Type help once it stops to\nsee the available commands.")
+	fs := addFilesystemFlags(flags)
+	flags.Parse(arguments)
+	fs.configure()
+
+	args := flags.Args()
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, "You need to tell me what file to debug:")
+		fmt.Fprintln(os.Stderr, "\tlainoa debug path/to/file.ln [args...]")
+		os.Exit(1)
+	}
+
+	os.Exit(debugger.Start(args[0], args[1:]))
+}
+
+func serveDAP(arguments []string) {
+	flags := newFlagSet("dap", "[options]",
+		"Serves the Debug Adapter Protocol over stdin and stdout, for editors to\ndebug programs.")
+	fs := addFilesystemFlags(flags)
+	flags.Parse(arguments)
+	fs.configure()
+
+	if err := dap.NewServer(os.Stdin, os.Stdout).Serve(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error serving the debug adapter:", err)
+		os.Exit(1)
+	}
+}
+
+func test(arguments []string) {
+	flags := newFlagSet("test", "[options] [directory]",
+		"Runs the test_ functions in the *_test.ln files of a directory, the current\none by default.")
+	run := flags.String("run", "", "only run tests whose name matches this regular expression")
+	fs := addFilesystemFlags(flags)
+	cover := addCoverageFlags(flags)
+	flags.Parse(arguments)
+	fs.configure()
+
+	path := "."
+	if flags.NArg() > 0 {
+		path = flags.Arg(0)
+	}
+
+	var filter *regexp.Regexp
+	if *run != "" {
+		var err error
+		if filter, err = regexp.Compile(*run); err != nil {
+			fmt.Fprintln(os.Stderr, "Invalid --run expression:", err)
+			os.Exit(1)
+		}
+	}
+
+	profile := cover.start()
+	summary, err := tester.Run(path, filter, os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error finding tests:", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n%d passed, %d failed\n", summary.Passed, summary.Failed)
+	cover.finish(profile, os.Stdout)
+	if summary.Failed > 0 {
+		os.Exit(1)
+	}
 }
 
-func startRepl() {
+func startRepl(arguments []string) {
+	flags := newFlagSet("repl", "[options]",
+		"Starts the interactive console. Type :help in it to see its commands.\nSet the NO_COLOR environment variable to turn highlighting off too.")
+	fs := addFilesystemFlags(flags)
+	noColor := flags.Bool("no-color", false, "don't highlight the code as it's typed")
+	history := flags.String("history", repl.DefaultHistoryFile(), "file to keep the input history in, empty to keep none")
+	flags.Parse(arguments)
+	fs.configure()
+
 	user, err := user.Current()
 	if err != nil {
 		panic(err)
@@ -38,26 +322,211 @@ func startRepl() {
 		user.Username)
 	fmt.Println("\nGo ahead and enter some code!")
 
-	repl.Start()
+	repl.Start(repl.Config{
+		Colors:      !*noColor && os.Getenv("NO_COLOR") == "",
+		HistoryFile: *history,
+	})
 }
 
-func main() {
-	if len(os.Args) < 2 {
-		fmt.Println("You need to tell me what to do!")
-		printHelp()
+// readSource reads the file in path, or stdin when path is -, exiting if
+// it can't.
+func readSource(path string) string {
+	var data []byte
+	var err error
+	if path == runner.STDIN {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(path)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error reading file", err)
+		os.Exit(1)
+	}
+
+	return string(data)
+}
+
+func tokens(arguments []string) {
+	flags := newFlagSet("tokens", "path/to/file.ln",
+		"Prints the tokens in a file, or in stdin when the path is -, with the line\nthey're in, their type and their literal.")
+	flags.Parse(arguments)
+
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "You need to tell me what file to read:")
+		fmt.Fprintln(os.Stderr, "\tlainoa tokens path/to/file.ln")
+		os.Exit(1)
+	}
+
+	path := flags.Arg(0)
+	l := lexer.New(readSource(path), path)
+	for {
+		t := l.NextToken()
+		fmt.Printf("%s:%d\t%-10s %q\n", t.Metadata.File, t.Metadata.Line, t.Type, t.Literal)
+		if t.Type == token.EOF {
+			return
+		}
+	}
+}
+
+func printAST(arguments []string) {
+	flags := newFlagSet("ast", "[options] path/to/file.ln",
+		"Prints the syntax tree of a file, or of stdin when the path is -, with the\nkind and line of every node.")
+	format := flags.String("format", "outline", "how to print the tree, outline or json")
+	flags.Parse(arguments)
+
+	if *format != "outline" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "Invalid --format %q, it can be outline or json\n", *format)
+		os.Exit(1)
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "You need to tell me what file to read:")
+		fmt.Fprintln(os.Stderr, "\tlainoa ast path/to/file.ln")
+		os.Exit(1)
+	}
+
+	path := flags.Arg(0)
+	p := parser.New(lexer.New(readSource(path), path))
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		fmt.Fprintln(os.Stderr, "Oops! Something is wrong here:")
+		fmt.Fprintln(os.Stderr, "  parser errors:")
+		for _, err := range p.Errors() {
+			fmt.Fprintln(os.Stderr, fmt.Sprintf("- %s\n", err.String()))
+		}
+		os.Exit(1)
+	}
+
+	tree := ast.Serialize(program)
+	if *format == "outline" {
+		tree.WriteOutline(os.Stdout)
 		return
 	}
-	action := os.Args[1]
 
-	switch action {
+	data, err := json.MarshalIndent(tree, "", "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing the tree:", err)
+		os.Exit(1)
+	}
+	fmt.Println(string(data))
+}
+
+const lintConfigFile = ".lainoa-lint.json"
+
+func lintFiles(arguments []string) {
+	description := "Checks the *.ln files in the paths, the current directory by default, and\nprints the problems it finds. Every rule is enabled unless the config file\nturns it off, as in {\"rules\": {\"unused-parameter\": false}}.\n\nRules:\n"
+	for _, rule := range lint.Rules {
+		description += fmt.Sprintf("  %-22s%s\n", rule.Name, rule.Description)
+	}
+
+	flags := newFlagSet("lint", "[options] [paths...]", strings.TrimSuffix(description, "\n"))
+	configPath := flags.String("config", lintConfigFile, "JSON file enabling or disabling rules, ignored when it's the default and doesn't exist")
+	format := flags.String("format", "text", "how to print the problems, text or json")
+	flags.Parse(arguments)
+
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "Invalid --format %q, it can be text or json\n", *format)
+		os.Exit(1)
+	}
+
+	config := lint.Config{}
+	if _, err := os.Stat(*configPath); err == nil || *configPath != lintConfigFile {
+		if config, err = lint.LoadConfig(*configPath); err != nil {
+			fmt.Fprintln(os.Stderr, "Error reading the lint config:", err)
+			os.Exit(1)
+		}
+	}
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	diagnostics, err := lint.LintFiles(paths, config)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error reading files:", err)
+		os.Exit(1)
+	}
+
+	if *format == "text" {
+		err = lint.WriteText(os.Stdout, diagnostics)
+	} else {
+		err = lint.WriteJSON(os.Stdout, diagnostics)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing the problems:", err)
+		os.Exit(1)
+	}
+
+	if len(diagnostics) > 0 {
+		os.Exit(1)
+	}
+}
+
+// command returns the function running the command with a name, or nil
+// if there's none.
+func command(name string) func(arguments []string) {
+	switch name {
 	case "run":
-		run()
+		return run
+	case "eval":
+		return eval
+	case "test":
+		return test
+	case "debug":
+		return debug
+	case "dap":
+		return serveDAP
+	case "tokens":
+		return tokens
+	case "ast":
+		return printAST
+	case "lint":
+		return lintFiles
 	case "repl":
-		startRepl()
+		return startRepl
 	case "help":
-		printHelp()
+		return help
 	default:
-		fmt.Printf("Command %s not supported\n", action)
+		return nil
+	}
+}
+
+func help(arguments []string) {
+	if len(arguments) == 0 {
 		printHelp()
+		return
 	}
+
+	cmd := command(arguments[0])
+	if cmd == nil || arguments[0] == "help" {
+		fmt.Printf("Command %s not supported\n", arguments[0])
+		printHelp()
+		os.Exit(1)
+	}
+	cmd([]string{"--help"})
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Println("You need to tell me what to do!")
+		printHelp()
+		os.Exit(1)
+	}
+	action := os.Args[1]
+
+	if cmd := command(action); cmd != nil {
+		cmd(os.Args[2:])
+		return
+	}
+
+	// lainoa path/to/file.ln, as scripts starting with #!/usr/bin/env lainoa do
+	if info, err := os.Stat(action); err == nil && !info.IsDir() {
+		run(os.Args[1:])
+		return
+	}
+
+	fmt.Printf("Command %s not supported\n", action)
+	printHelp()
+	os.Exit(1)
 }
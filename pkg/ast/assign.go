@@ -7,7 +7,7 @@ import (
 )
 
 type AssignExpression struct {
-	Token token.Token // token.ASSIGN
+	Token token.Token // token.ASSIGN or a compound one, e.g. token.PLUS_ASSIGN
 	Name  *Identifier
 	Value Expression
 }
@@ -19,9 +19,29 @@ func (ae *AssignExpression) String() string {
 	var out bytes.Buffer
 
 	out.WriteString(ae.Name.String())
-	out.WriteString(" = ")
+	out.WriteString(" " + ae.Token.Literal + " ")
 	out.WriteString(ae.Value.String())
 	out.WriteString(";")
 
 	return out.String()
 }
+
+type IndexAssignExpression struct {
+	Token  token.Token // token.ASSIGN or a compound one, e.g. token.PLUS_ASSIGN
+	Target *IndexExpression
+	Value  Expression
+}
+
+func (ia *IndexAssignExpression) expressionNode()      {}
+func (ia *IndexAssignExpression) TokenLiteral() string { return ia.Token.Literal }
+
+func (ia *IndexAssignExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ia.Target.String())
+	out.WriteString(" " + ia.Token.Literal + " ")
+	out.WriteString(ia.Value.String())
+	out.WriteString(";")
+
+	return out.String()
+}
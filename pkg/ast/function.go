@@ -8,9 +8,12 @@ import (
 )
 
 type FunctionLiteral struct {
-	Token      token.Token // token.IF
+	Token      token.Token // token.FUNCTION
 	Parameters []*Identifier
+	Defaults   map[string]Expression // default values, by parameter name
+	Rest       *Identifier           // collects extra arguments, as in fun(...rest)
 	Body       *BlockStatement
+	Name       string // the name it's declared or let-bound with, empty if none
 }
 
 func (f *FunctionLiteral) expressionNode()      {}
@@ -18,16 +21,51 @@ func (f *FunctionLiteral) TokenLiteral() string { return f.Token.Literal }
 func (f *FunctionLiteral) String() string {
 	var out bytes.Buffer
 
-	params := []string{}
-	for _, p := range f.Parameters {
-		params = append(params, p.String())
-	}
-
 	out.WriteString(f.TokenLiteral())
 	out.WriteString("(")
-	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(ParametersString(f.Parameters, f.Defaults, f.Rest))
 	out.WriteString(") ")
 	out.WriteString(f.Body.String())
 
 	return out.String()
 }
+
+// ParametersString formats a parameter list as it's written in the source,
+// e.g. "a, b = 2, ...rest".
+func ParametersString(params []*Identifier, defaults map[string]Expression, rest *Identifier) string {
+	formatted := []string{}
+	for _, p := range params {
+		if def, ok := defaults[p.Value]; ok {
+			formatted = append(formatted, p.String()+" = "+def.String())
+		} else {
+			formatted = append(formatted, p.String())
+		}
+	}
+
+	if rest != nil {
+		formatted = append(formatted, "..."+rest.String())
+	}
+
+	return strings.Join(formatted, ", ")
+}
+
+type FunctionDeclaration struct {
+	Token    token.Token // token.FUNCTION
+	Name     *Identifier
+	Function *FunctionLiteral
+}
+
+func (fd *FunctionDeclaration) statementNode()       {}
+func (fd *FunctionDeclaration) TokenLiteral() string { return fd.Token.Literal }
+func (fd *FunctionDeclaration) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(fd.TokenLiteral() + " ")
+	out.WriteString(fd.Name.String())
+	out.WriteString("(")
+	out.WriteString(ParametersString(fd.Function.Parameters, fd.Function.Defaults, fd.Function.Rest))
+	out.WriteString(") ")
+	out.WriteString(fd.Function.Body.String())
+
+	return out.String()
+}
@@ -0,0 +1,32 @@
+package ast
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/uesteibar/lainoa/pkg/token"
+)
+
+type HashLiteral struct {
+	Token  token.Token // token.LBRACE '{'
+	Keys   []Expression
+	Values []Expression // Values[i] is the value for Keys[i]
+}
+
+func (hl *HashLiteral) expressionNode()      {}
+func (hl *HashLiteral) TokenLiteral() string { return hl.Token.Literal }
+
+func (hl *HashLiteral) String() string {
+	var out bytes.Buffer
+
+	pairs := []string{}
+	for i, key := range hl.Keys {
+		pairs = append(pairs, key.String()+": "+hl.Values[i].String())
+	}
+
+	out.WriteString("{")
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
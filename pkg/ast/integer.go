@@ -1,6 +1,7 @@
 package ast
 
 import (
+	"math/big"
 	"strconv"
 
 	"github.com/uesteibar/lainoa/pkg/token"
@@ -9,8 +10,14 @@ import (
 type IntegerLiteral struct {
 	Token token.Token // token.INT
 	Value int64
+	Big   *big.Int // the value when it doesn't fit in Value, nil otherwise
 }
 
 func (i *IntegerLiteral) expressionNode()      {}
 func (i *IntegerLiteral) TokenLiteral() string { return i.Token.Literal }
-func (i *IntegerLiteral) String() string       { return strconv.Itoa(int(i.Value)) }
+func (i *IntegerLiteral) String() string {
+	if i.Big != nil {
+		return i.Big.String()
+	}
+	return strconv.Itoa(int(i.Value))
+}
@@ -0,0 +1,27 @@
+package ast
+
+import (
+	"bytes"
+
+	"github.com/uesteibar/lainoa/pkg/token"
+)
+
+type PipeExpression struct {
+	Token token.Token // token.PIPE
+	Left  Expression
+	Right Expression // the function, or a call the left value gets inserted into
+}
+
+func (pe *PipeExpression) expressionNode()      {}
+func (pe *PipeExpression) TokenLiteral() string { return pe.Token.Literal }
+func (pe *PipeExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(pe.Left.String())
+	out.WriteString(" |> ")
+	out.WriteString(pe.Right.String())
+	out.WriteString(")")
+
+	return out.String()
+}
@@ -0,0 +1,276 @@
+package ast
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/uesteibar/lainoa/pkg/token"
+)
+
+// Tree is a node in a form that's easy to print or serialize: its kind,
+// where it starts in the source and its fields, in source order.
+type Tree struct {
+	Kind     string
+	Position token.Metadata
+	Fields   []Field
+}
+
+// Field is a named part of a node. Its value is a string, an int64, a
+// *big.Int or a bool for the node's own data, a *Tree for a child node, a []*Tree for a
+// list of them, or nil for an optional child that's missing.
+type Field struct {
+	Name  string
+	Value interface{}
+}
+
+// Serialize turns a node and everything in it into a Tree. Hash pairs and
+// parameters don't have a node of their own, so they're shown with the
+// kinds HashPair and Parameter.
+func Serialize(node Node) *Tree {
+	switch node := node.(type) {
+	case nil:
+		return nil
+	case *Program:
+		return &Tree{Kind: "Program", Fields: []Field{{"statements", serializeStatements(node.Statements)}}}
+	case *LetStatement:
+		return tree("LetStatement", node.Token,
+			Field{"name", serializeIdentifier(node.Name)},
+			Field{"value", Serialize(node.Value)},
+		)
+	case *ReturnStatement:
+		return tree("ReturnStatement", node.Token, Field{"value", Serialize(node.Value)})
+	case *ExpressionStatement:
+		return tree("ExpressionStatement", node.Token, Field{"expression", Serialize(node.Expression)})
+	case *BlockStatement:
+		return tree("BlockStatement", node.Token, Field{"statements", serializeStatements(node.Statements)})
+	case *FunctionDeclaration:
+		return tree("FunctionDeclaration", node.Token,
+			Field{"name", serializeIdentifier(node.Name)},
+			Field{"function", Serialize(node.Function)},
+		)
+	case *Identifier:
+		return tree("Identifier", node.Token, Field{"value", node.Value})
+	case *IntegerLiteral:
+		if node.Big != nil {
+			return tree("IntegerLiteral", node.Token, Field{"value", node.Big})
+		}
+		return tree("IntegerLiteral", node.Token, Field{"value", node.Value})
+	case *StringLiteral:
+		return tree("StringLiteral", node.Token, Field{"value", node.Value})
+	case *Boolean:
+		return tree("Boolean", node.Token, Field{"value", node.Value})
+	case *NilLiteral:
+		return tree("NilLiteral", node.Token)
+	case *PrefixExpression:
+		return tree("PrefixExpression", node.Token,
+			Field{"operator", node.Operator},
+			Field{"right", Serialize(node.Right)},
+		)
+	case *InfixExpression:
+		return tree("InfixExpression", node.Token,
+			Field{"operator", node.Operator},
+			Field{"left", Serialize(node.Left)},
+			Field{"right", Serialize(node.Right)},
+		)
+	case *IfExpression:
+		return tree("IfExpression", node.Token,
+			Field{"condition", Serialize(node.Condition)},
+			Field{"consequence", serializeBlock(node.Consequence)},
+			Field{"alternative", serializeBlock(node.Alternative)},
+		)
+	case *FunctionLiteral:
+		fields := []Field{}
+		if node.Name != "" {
+			fields = append(fields, Field{"name", node.Name})
+		}
+		params := []*Tree{}
+		for _, param := range node.Parameters {
+			params = append(params, tree("Parameter", param.Token,
+				Field{"name", param.Value},
+				Field{"default", Serialize(node.Defaults[param.Value])},
+			))
+		}
+		fields = append(fields,
+			Field{"parameters", params},
+			Field{"rest", serializeIdentifier(node.Rest)},
+			Field{"body", serializeBlock(node.Body)},
+		)
+		return tree("FunctionLiteral", node.Token, fields...)
+	case *CallExpression:
+		return tree("CallExpression", node.Token,
+			Field{"function", Serialize(node.Function)},
+			Field{"arguments", serializeExpressions(node.Arguments)},
+		)
+	case *PipeExpression:
+		return tree("PipeExpression", node.Token,
+			Field{"left", Serialize(node.Left)},
+			Field{"right", Serialize(node.Right)},
+		)
+	case *ArrayExpression:
+		return tree("ArrayExpression", node.Token, Field{"elements", serializeExpressions(node.Expressions)})
+	case *HashLiteral:
+		pairs := []*Tree{}
+		for i, key := range node.Keys {
+			keyTree := Serialize(key)
+			pairs = append(pairs, &Tree{
+				Kind:     "HashPair",
+				Position: keyTree.Position,
+				Fields:   []Field{{"key", keyTree}, {"value", Serialize(node.Values[i])}},
+			})
+		}
+		return tree("HashLiteral", node.Token, Field{"pairs", pairs})
+	case *IndexExpression:
+		return tree("IndexExpression", node.Token,
+			Field{"left", Serialize(node.Left)},
+			Field{"index", Serialize(node.Index)},
+		)
+	case *SliceExpression:
+		return tree("SliceExpression", node.Token,
+			Field{"left", Serialize(node.Left)},
+			Field{"start", Serialize(node.Start)},
+			Field{"end", Serialize(node.End)},
+		)
+	case *AssignExpression:
+		return tree("AssignExpression", node.Token,
+			Field{"operator", node.Token.Literal},
+			Field{"name", serializeIdentifier(node.Name)},
+			Field{"value", Serialize(node.Value)},
+		)
+	case *IndexAssignExpression:
+		return tree("IndexAssignExpression", node.Token,
+			Field{"operator", node.Token.Literal},
+			Field{"target", Serialize(node.Target)},
+			Field{"value", Serialize(node.Value)},
+		)
+	case *SpreadExpression:
+		return tree("SpreadExpression", node.Token, Field{"value", Serialize(node.Value)})
+	default:
+		panic(fmt.Sprintf("can't serialize %T", node))
+	}
+}
+
+func tree(kind string, t token.Token, fields ...Field) *Tree {
+	if fields == nil {
+		fields = []Field{}
+	}
+
+	return &Tree{Kind: kind, Position: t.Metadata, Fields: fields}
+}
+
+// A missing block or identifier would turn into a Node that isn't nil, so
+// these check for them first.
+
+func serializeBlock(block *BlockStatement) *Tree {
+	if block == nil {
+		return nil
+	}
+
+	return Serialize(block)
+}
+
+func serializeIdentifier(ident *Identifier) *Tree {
+	if ident == nil {
+		return nil
+	}
+
+	return Serialize(ident)
+}
+
+func serializeStatements(stmts []Statement) []*Tree {
+	trees := []*Tree{}
+	for _, stmt := range stmts {
+		trees = append(trees, Serialize(stmt))
+	}
+
+	return trees
+}
+
+func serializeExpressions(exprs []Expression) []*Tree {
+	trees := []*Tree{}
+	for _, expr := range exprs {
+		trees = append(trees, Serialize(expr))
+	}
+
+	return trees
+}
+
+// MarshalJSON writes the tree as an object with its kind and line,
+// followed by its fields.
+func (t *Tree) MarshalJSON() ([]byte, error) {
+	var out bytes.Buffer
+
+	out.WriteString(`{"kind":`)
+	writeJSON(&out, t.Kind)
+	if t.Position.Line != 0 {
+		out.WriteString(`,"line":`)
+		writeJSON(&out, t.Position.Line)
+	}
+
+	for _, field := range t.Fields {
+		out.WriteString(",")
+		writeJSON(&out, field.Name)
+		out.WriteString(":")
+		if err := writeJSON(&out, field.Value); err != nil {
+			return nil, err
+		}
+	}
+	out.WriteString("}")
+
+	return out.Bytes(), nil
+}
+
+func writeJSON(out *bytes.Buffer, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	out.Write(data)
+	return nil
+}
+
+// WriteOutline writes the tree indented, one node per line with its kind,
+// line and own data, and its children below it.
+func (t *Tree) WriteOutline(w io.Writer) error {
+	var out bytes.Buffer
+	t.writeOutline(&out, "", 0)
+
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+func (t *Tree) writeOutline(out *bytes.Buffer, label string, depth int) {
+	indent := strings.Repeat("  ", depth)
+
+	out.WriteString(indent + label + t.Kind)
+	for _, field := range t.Fields {
+		switch value := field.Value.(type) {
+		case string:
+			out.WriteString(" " + field.Name + "=" + strconv.Quote(value))
+		case int64, bool, *big.Int:
+			out.WriteString(fmt.Sprintf(" %s=%v", field.Name, value))
+		}
+	}
+	if t.Position.Line != 0 {
+		out.WriteString(fmt.Sprintf(" (line %d)", t.Position.Line))
+	}
+	out.WriteString("\n")
+
+	for _, field := range t.Fields {
+		switch value := field.Value.(type) {
+		case *Tree:
+			if value != nil {
+				value.writeOutline(out, field.Name+": ", depth+1)
+			}
+		case []*Tree:
+			for i, child := range value {
+				child.writeOutline(out, fmt.Sprintf("%s[%d]: ", field.Name, i), depth+1)
+			}
+		}
+	}
+}
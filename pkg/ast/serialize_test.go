@@ -0,0 +1,120 @@
+package ast_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uesteibar/lainoa/pkg/ast"
+	"github.com/uesteibar/lainoa/pkg/lexer"
+	"github.com/uesteibar/lainoa/pkg/parser"
+)
+
+// everyNode is a program with every kind of node in it.
+const everyNode = `let a = -1 + 2
+fun add(x, y = 1, ...rest) { return x + y }
+let f = fun() { nil }
+if (true) { a = 2 } else { a += 3 }
+let list = [1, "two", false]
+list[0] = list[1:] |> len
+let hash = {"key": add(...list)}
+hash["key"][:2]
+`
+
+func parse(t *testing.T, input string) *ast.Program {
+	p := parser.New(lexer.New(input, "file.ln"))
+	program := p.ParseProgram()
+	assert.Empty(t, p.Errors())
+
+	return program
+}
+
+func kinds(tree *ast.Tree, found map[string]bool) {
+	if tree == nil {
+		return
+	}
+
+	found[tree.Kind] = true
+	for _, field := range tree.Fields {
+		switch value := field.Value.(type) {
+		case *ast.Tree:
+			kinds(value, found)
+		case []*ast.Tree:
+			for _, child := range value {
+				kinds(child, found)
+			}
+		}
+	}
+}
+
+func TestSerializeEveryNode(t *testing.T) {
+	found := map[string]bool{}
+	kinds(ast.Serialize(parse(t, everyNode)), found)
+
+	for _, kind := range []string{
+		"Program", "LetStatement", "ReturnStatement", "ExpressionStatement", "BlockStatement",
+		"FunctionDeclaration", "Identifier", "IntegerLiteral", "StringLiteral", "Boolean", "NilLiteral",
+		"PrefixExpression", "InfixExpression", "IfExpression", "FunctionLiteral", "Parameter",
+		"CallExpression", "PipeExpression", "ArrayExpression", "HashLiteral", "HashPair",
+		"IndexExpression", "SliceExpression", "AssignExpression", "IndexAssignExpression", "SpreadExpression",
+	} {
+		assert.True(t, found[kind], kind)
+	}
+}
+
+func TestWriteOutline(t *testing.T) {
+	program := parse(t, "let add = fun(x, y = 1) {\n  x + y\n}\nadd(2)[:1]")
+
+	var out bytes.Buffer
+	assert.NoError(t, ast.Serialize(program).WriteOutline(&out))
+
+	assert.Equal(t, `Program
+  statements[0]: LetStatement (line 1)
+    name: Identifier value="add" (line 1)
+    value: FunctionLiteral name="add" (line 1)
+      parameters[0]: Parameter name="x" (line 1)
+      parameters[1]: Parameter name="y" (line 1)
+        default: IntegerLiteral value=1 (line 1)
+      body: BlockStatement (line 1)
+        statements[0]: ExpressionStatement (line 2)
+          expression: InfixExpression operator="+" (line 2)
+            left: Identifier value="x" (line 2)
+            right: Identifier value="y" (line 2)
+  statements[1]: ExpressionStatement (line 4)
+    expression: SliceExpression (line 4)
+      left: CallExpression (line 4)
+        function: Identifier value="add" (line 4)
+        arguments[0]: IntegerLiteral value=2 (line 4)
+      end: IntegerLiteral value=1 (line 4)
+`, out.String())
+}
+
+func TestMarshalJSON(t *testing.T) {
+	program := parse(t, `x = {"a": !true}`)
+
+	data, err := json.Marshal(ast.Serialize(program))
+	assert.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"kind": "Program",
+		"statements": [{
+			"kind": "ExpressionStatement", "line": 1,
+			"expression": {
+				"kind": "AssignExpression", "line": 1, "operator": "=",
+				"name": {"kind": "Identifier", "line": 1, "value": "x"},
+				"value": {
+					"kind": "HashLiteral", "line": 1,
+					"pairs": [{
+						"kind": "HashPair", "line": 1,
+						"key": {"kind": "StringLiteral", "line": 1, "value": "a"},
+						"value": {
+							"kind": "PrefixExpression", "line": 1, "operator": "!",
+							"right": {"kind": "Boolean", "line": 1, "value": true}
+						}
+					}]
+				}
+			}
+		}]
+	}`, string(data))
+}
@@ -0,0 +1,34 @@
+package ast
+
+import (
+	"bytes"
+
+	"github.com/uesteibar/lainoa/pkg/token"
+)
+
+type SliceExpression struct {
+	Token token.Token // token.LBRACKET '['
+	Left  Expression
+	Start Expression // nil when omitted, as in array[:2]
+	End   Expression // nil when omitted, as in array[1:]
+}
+
+func (se *SliceExpression) expressionNode()      {}
+func (se *SliceExpression) TokenLiteral() string { return se.Token.Literal }
+
+func (se *SliceExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(se.Left.String())
+	out.WriteString("[")
+	if se.Start != nil {
+		out.WriteString(se.Start.String())
+	}
+	out.WriteString(":")
+	if se.End != nil {
+		out.WriteString(se.End.String())
+	}
+	out.WriteString("]")
+
+	return out.String()
+}
@@ -0,0 +1,14 @@
+package ast
+
+import (
+	"github.com/uesteibar/lainoa/pkg/token"
+)
+
+type SpreadExpression struct {
+	Token token.Token // token.ELLIPSIS
+	Value Expression
+}
+
+func (s *SpreadExpression) expressionNode()      {}
+func (s *SpreadExpression) TokenLiteral() string { return s.Token.Literal }
+func (s *SpreadExpression) String() string       { return "..." + s.Value.String() }
@@ -0,0 +1,313 @@
+package ast
+
+import "fmt"
+
+// A Visitor's Visit is called for every node Walk finds. If it returns a
+// visitor, Walk visits the children of the node with it, and calls its
+// Visit(nil) afterwards.
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses node and everything in it depth-first, in source order.
+// Parameters are visited as Identifiers, each followed by its default
+// value, and hash literals visit each key followed by its value.
+func Walk(node Node, v Visitor) {
+	if isNil(node) {
+		return
+	}
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	for _, child := range children(node) {
+		Walk(child, v)
+	}
+
+	v.Visit(nil)
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses node like Walk, calling f for every node and for nil
+// once the children of a node are done. Returning false from f skips the
+// children of the node.
+func Inspect(node Node, f func(Node) bool) {
+	Walk(node, inspector(f))
+}
+
+// children returns the nodes directly inside node, in source order. Missing
+// optional children are left out.
+func children(node Node) []Node {
+	nodes := []Node{}
+	add := func(children ...Node) {
+		for _, child := range children {
+			if !isNil(child) {
+				nodes = append(nodes, child)
+			}
+		}
+	}
+
+	switch node := node.(type) {
+	case *Program:
+		for _, stmt := range node.Statements {
+			add(stmt)
+		}
+	case *LetStatement:
+		add(node.Name, node.Value)
+	case *ReturnStatement:
+		add(node.Value)
+	case *ExpressionStatement:
+		add(node.Expression)
+	case *BlockStatement:
+		for _, stmt := range node.Statements {
+			add(stmt)
+		}
+	case *FunctionDeclaration:
+		add(node.Name, node.Function)
+	case *Identifier, *IntegerLiteral, *StringLiteral, *Boolean, *NilLiteral:
+	case *PrefixExpression:
+		add(node.Right)
+	case *InfixExpression:
+		add(node.Left, node.Right)
+	case *IfExpression:
+		add(node.Condition, node.Consequence, node.Alternative)
+	case *FunctionLiteral:
+		for _, param := range node.Parameters {
+			add(param, node.Defaults[param.Value])
+		}
+		add(node.Rest, node.Body)
+	case *CallExpression:
+		add(node.Function)
+		for _, arg := range node.Arguments {
+			add(arg)
+		}
+	case *PipeExpression:
+		add(node.Left, node.Right)
+	case *ArrayExpression:
+		for _, el := range node.Expressions {
+			add(el)
+		}
+	case *HashLiteral:
+		for i, key := range node.Keys {
+			add(key, node.Values[i])
+		}
+	case *IndexExpression:
+		add(node.Left, node.Index)
+	case *SliceExpression:
+		add(node.Left, node.Start, node.End)
+	case *AssignExpression:
+		add(node.Name, node.Value)
+	case *IndexAssignExpression:
+		add(node.Target, node.Value)
+	case *SpreadExpression:
+		add(node.Value)
+	default:
+		panic(fmt.Sprintf("can't walk %T", node))
+	}
+
+	return nodes
+}
+
+// isNil tells whether a node is missing, including typed nils such as a
+// nil *BlockStatement.
+func isNil(node Node) bool {
+	switch node := node.(type) {
+	case nil:
+		return true
+	case *BlockStatement:
+		return node == nil
+	case *Identifier:
+		return node == nil
+	case *FunctionLiteral:
+		return node == nil
+	case *IndexExpression:
+		return node == nil
+	default:
+		return false
+	}
+}
+
+// Modify replaces every node inside node, and node itself, with what f
+// returns for it, starting from the innermost ones, and returns the new
+// node. f gets the node with its children already replaced, and returning
+// it unchanged keeps it. Returning nil removes the node from the list it's
+// in, such as the statements of a block, the parameters or arguments of a
+// function or the pairs of a hash, and leaves an optional child, such as the
+// alternative of an if or the default of a parameter, out. Any other child
+// is left nil, for f to replace or remove its parent, like a statement whose
+// expression is gone; the tree can't be printed or run while it's nil.
+//
+// Replacements must fit where the original was: an expression can't take
+// the place of a statement, and the name of a let must stay an Identifier.
+// Modify panics if they don't.
+func Modify(node Node, f func(Node) Node) Node {
+	switch node := node.(type) {
+	case *Program:
+		node.Statements = modifyStatements(node.Statements, f)
+	case *LetStatement:
+		node.Name = modifyIdentifier(node.Name, f)
+		node.Value = modifyExpression(node.Value, f)
+	case *ReturnStatement:
+		node.Value = modifyExpression(node.Value, f)
+	case *ExpressionStatement:
+		node.Expression = modifyExpression(node.Expression, f)
+	case *BlockStatement:
+		node.Statements = modifyStatements(node.Statements, f)
+	case *FunctionDeclaration:
+		node.Name = modifyIdentifier(node.Name, f)
+		node.Function = modifyFunction(node.Function, f)
+	case *Identifier, *IntegerLiteral, *StringLiteral, *Boolean, *NilLiteral:
+	case *PrefixExpression:
+		node.Right = modifyExpression(node.Right, f)
+	case *InfixExpression:
+		node.Left = modifyExpression(node.Left, f)
+		node.Right = modifyExpression(node.Right, f)
+	case *IfExpression:
+		node.Condition = modifyExpression(node.Condition, f)
+		node.Consequence = modifyBlock(node.Consequence, f)
+		node.Alternative = modifyBlock(node.Alternative, f)
+	case *FunctionLiteral:
+		params := []*Identifier{}
+		for _, param := range node.Parameters {
+			def, hasDefault := node.Defaults[param.Value]
+			modified := modifyIdentifier(param, f)
+			if hasDefault {
+				delete(node.Defaults, param.Value)
+				def = modifyExpression(def, f)
+			}
+			if modified == nil {
+				continue
+			}
+
+			params = append(params, modified)
+			if def != nil {
+				node.Defaults[modified.Value] = def
+			}
+		}
+		node.Parameters = params
+		node.Rest = modifyIdentifier(node.Rest, f)
+		node.Body = modifyBlock(node.Body, f)
+	case *CallExpression:
+		node.Function = modifyExpression(node.Function, f)
+		node.Arguments = modifyExpressions(node.Arguments, f)
+	case *PipeExpression:
+		node.Left = modifyExpression(node.Left, f)
+		node.Right = modifyExpression(node.Right, f)
+	case *ArrayExpression:
+		node.Expressions = modifyExpressions(node.Expressions, f)
+	case *HashLiteral:
+		keys, values := []Expression{}, []Expression{}
+		for i := range node.Keys {
+			key := modifyExpression(node.Keys[i], f)
+			value := modifyExpression(node.Values[i], f)
+			if key != nil && value != nil {
+				keys, values = append(keys, key), append(values, value)
+			}
+		}
+		node.Keys, node.Values = keys, values
+	case *IndexExpression:
+		node.Left = modifyExpression(node.Left, f)
+		node.Index = modifyExpression(node.Index, f)
+	case *SliceExpression:
+		node.Left = modifyExpression(node.Left, f)
+		node.Start = modifyExpression(node.Start, f)
+		node.End = modifyExpression(node.End, f)
+	case *AssignExpression:
+		node.Name = modifyIdentifier(node.Name, f)
+		node.Value = modifyExpression(node.Value, f)
+	case *IndexAssignExpression:
+		node.Target = modifyIndex(node.Target, f)
+		node.Value = modifyExpression(node.Value, f)
+	case *SpreadExpression:
+		node.Value = modifyExpression(node.Value, f)
+	default:
+		panic(fmt.Sprintf("can't modify %T", node))
+	}
+
+	return f(node)
+}
+
+func modifyStatements(stmts []Statement, f func(Node) Node) []Statement {
+	modified := []Statement{}
+	for _, stmt := range stmts {
+		if stmt := Modify(stmt, f); stmt != nil {
+			modified = append(modified, stmt.(Statement))
+		}
+	}
+
+	return modified
+}
+
+func modifyExpressions(exprs []Expression, f func(Node) Node) []Expression {
+	modified := []Expression{}
+	for _, expr := range exprs {
+		if expr := Modify(expr, f); expr != nil {
+			modified = append(modified, expr.(Expression))
+		}
+	}
+
+	return modified
+}
+
+func modifyExpression(expr Expression, f func(Node) Node) Expression {
+	if expr == nil {
+		return nil
+	}
+	if modified := Modify(expr, f); modified != nil {
+		return modified.(Expression)
+	}
+
+	return nil
+}
+
+func modifyBlock(block *BlockStatement, f func(Node) Node) *BlockStatement {
+	if block == nil {
+		return nil
+	}
+	if modified := Modify(block, f); modified != nil {
+		return modified.(*BlockStatement)
+	}
+
+	return nil
+}
+
+func modifyFunction(fn *FunctionLiteral, f func(Node) Node) *FunctionLiteral {
+	if fn == nil {
+		return nil
+	}
+	if modified := Modify(fn, f); modified != nil {
+		return modified.(*FunctionLiteral)
+	}
+
+	return nil
+}
+
+func modifyIndex(index *IndexExpression, f func(Node) Node) *IndexExpression {
+	if index == nil {
+		return nil
+	}
+	if modified := Modify(index, f); modified != nil {
+		return modified.(*IndexExpression)
+	}
+
+	return nil
+}
+
+func modifyIdentifier(ident *Identifier, f func(Node) Node) *Identifier {
+	if ident == nil {
+		return nil
+	}
+	if modified := Modify(ident, f); modified != nil {
+		return modified.(*Identifier)
+	}
+
+	return nil
+}
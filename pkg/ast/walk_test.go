@@ -0,0 +1,192 @@
+package ast_test
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	goast "go/ast"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uesteibar/lainoa/pkg/ast"
+)
+
+// nodeTypes finds every type in the package that implements ast.Node, so
+// new ones can't be forgotten by the traversals.
+func nodeTypes(t *testing.T) []string {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, ".", func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, 0)
+	assert.NoError(t, err)
+
+	types := []string{}
+	for _, file := range pkgs["ast"].Files {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*goast.FuncDecl)
+			if !ok || fn.Recv == nil || fn.Name.Name != "TokenLiteral" {
+				continue
+			}
+
+			star := fn.Recv.List[0].Type.(*goast.StarExpr)
+			types = append(types, star.X.(*goast.Ident).Name)
+		}
+	}
+	assert.NotEmpty(t, types)
+
+	return types
+}
+
+func typeName(node ast.Node) string {
+	return reflect.TypeOf(node).Elem().Name()
+}
+
+func TestTraversalsCoverEveryNodeType(t *testing.T) {
+	inspected := map[string]bool{}
+	ast.Inspect(parse(t, everyNode), func(node ast.Node) bool {
+		if node != nil {
+			inspected[typeName(node)] = true
+		}
+		return true
+	})
+
+	modified := map[string]bool{}
+	ast.Modify(parse(t, everyNode), func(node ast.Node) ast.Node {
+		modified[typeName(node)] = true
+		return node
+	})
+
+	serialized := map[string]bool{}
+	kinds(ast.Serialize(parse(t, everyNode)), serialized)
+
+	for _, name := range nodeTypes(t) {
+		assert.True(t, inspected[name], "Inspect doesn't get to %s, is it in everyNode?", name)
+		assert.True(t, modified[name], "Modify doesn't get to %s, is it in everyNode?", name)
+		assert.True(t, serialized[name], "Serialize doesn't get to %s, is it in everyNode?", name)
+	}
+}
+
+type recorder struct {
+	visits *[]string
+}
+
+func (r recorder) Visit(node ast.Node) ast.Visitor {
+	if node == nil {
+		*r.visits = append(*r.visits, "end")
+		return nil
+	}
+
+	*r.visits = append(*r.visits, node.String())
+	if _, ok := node.(*ast.FunctionLiteral); ok {
+		return nil
+	}
+	return r
+}
+
+func TestWalk(t *testing.T) {
+	visits := []string{}
+	ast.Walk(parse(t, "let a = add(1, -b, fun(x = 2) { x })"), recorder{&visits})
+
+	assert.Equal(t, []string{
+		"let a = add(1, (-b), fun(x = 2) x);",
+		"let a = add(1, (-b), fun(x = 2) x);",
+		"a", "end",
+		"add(1, (-b), fun(x = 2) x)",
+		"add", "end",
+		"1", "end",
+		"(-b)", "b", "end", "end",
+		"fun(x = 2) x",
+		"end",
+		"end",
+		"end",
+	}, visits)
+}
+
+func TestInspect(t *testing.T) {
+	program := parse(t, `fun add(a, b = 1, ...rest) { a + b }
+{"k": [a, b][0:1]}`)
+
+	identifiers := []string{}
+	ast.Inspect(program, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Identifier); ok {
+			identifiers = append(identifiers, ident.Value)
+		}
+		_, isFunction := node.(*ast.FunctionLiteral)
+		return !isFunction
+	})
+
+	assert.Equal(t, []string{"add", "a", "b"}, identifiers)
+
+	identifiers = []string{}
+	ast.Inspect(program, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Identifier); ok {
+			identifiers = append(identifiers, ident.Value)
+		}
+		return true
+	})
+
+	assert.Equal(t, []string{"add", "a", "b", "rest", "a", "b", "a", "b"}, identifiers)
+}
+
+func TestModify(t *testing.T) {
+	double := func(node ast.Node) ast.Node {
+		if integer, ok := node.(*ast.IntegerLiteral); ok {
+			integer.Value *= 2
+			return integer
+		}
+		return node
+	}
+	dropPuts := func(node ast.Node) ast.Node {
+		if call, ok := node.(*ast.CallExpression); ok && call.Function.String() == "puts" {
+			return nil
+		}
+		if stmt, ok := node.(*ast.ExpressionStatement); ok && stmt.Expression == nil {
+			return nil
+		}
+		return node
+	}
+	renameX := func(node ast.Node) ast.Node {
+		if ident, ok := node.(*ast.Identifier); ok && ident.Value == "x" {
+			return &ast.Identifier{Token: ident.Token, Value: "y"}
+		}
+		return node
+	}
+	dropA := func(node ast.Node) ast.Node {
+		if ident, ok := node.(*ast.Identifier); ok && ident.Value == "a" {
+			return nil
+		}
+		return node
+	}
+	dropFunctions := func(node ast.Node) ast.Node {
+		if _, ok := node.(*ast.FunctionLiteral); ok {
+			return nil
+		}
+		if decl, ok := node.(*ast.FunctionDeclaration); ok && decl.Function == nil {
+			return nil
+		}
+		return node
+	}
+
+	tests := []struct {
+		input    string
+		modify   func(ast.Node) ast.Node
+		expected string
+	}{
+		{"1 + 2; [3, {4: 5}][6:7]", double, "(2 + 4)[6, {8: 10}][12:14]"},
+		{"if (true) { 1 } else { 2 }", double, "if true 2 else 4"},
+		{"fun f(a = 1) { a }; f(2)", double, "fun f(a = 2) af(4)"},
+		{"let a = 1; puts(a); a", dropPuts, "let a = 1;a"},
+		{"[puts(1), 2]; len(puts(1))", dropPuts, "[2]len()"},
+		{"let f = fun(x = 1) { x }; x = 2", renameX, "let f = fun(y = 1) y;y = 2;"},
+		{"fun f(x, a = 1, b = 2) { b }", dropA, "fun f(x, b = 2) b"},
+		{"fun f() { 1 }; 2", dropFunctions, "2"},
+	}
+
+	for _, tt := range tests {
+		modified := ast.Modify(parse(t, tt.input), tt.modify)
+		assert.Equal(t, tt.expected, modified.String(), tt.input)
+	}
+}
@@ -0,0 +1,155 @@
+package coverage
+
+import (
+	"sort"
+
+	"github.com/uesteibar/lainoa/pkg/ast"
+	"github.com/uesteibar/lainoa/pkg/token"
+)
+
+type Kind string
+
+// Kinds of code that are counted. Every `if` has an else branch, even when
+// it's not written, because not taking the consequence is a path too.
+const (
+	STATEMENT   = Kind("statement")
+	THEN_BRANCH = Kind("then")
+	ELSE_BRANCH = Kind("else")
+)
+
+// Block identifies the statements or the `if` branch in a line of a file.
+type Block struct {
+	File string
+	Line int
+	Kind Kind
+}
+
+// Profile counts how many times each block has been evaluated.
+type Profile struct {
+	counts map[Block]int
+}
+
+func New() *Profile {
+	return &Profile{counts: make(map[Block]int)}
+}
+
+// AddProgram registers every statement and branch in the program, so that
+// the ones that never run show up in the profile. Adding a program more
+// than once doesn't reset its counts.
+func (p *Profile) AddProgram(program *ast.Program) {
+	ast.Inspect(program, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.LetStatement, *ast.ReturnStatement, *ast.ExpressionStatement, *ast.FunctionDeclaration:
+			p.register(statementBlock(node.(ast.Statement)))
+		case *ast.IfExpression:
+			p.register(branchBlock(node, true))
+			p.register(branchBlock(node, false))
+		}
+		return true
+	})
+}
+
+func (p *Profile) RecordStatement(stmt ast.Statement) {
+	p.counts[statementBlock(stmt)]++
+}
+
+// RecordBranch records which branch of the `if` was taken.
+func (p *Profile) RecordBranch(ifexp *ast.IfExpression, consequence bool) {
+	p.counts[branchBlock(ifexp, consequence)]++
+}
+
+func (p *Profile) Count(block Block) int {
+	return p.counts[block]
+}
+
+// Blocks returns the registered blocks sorted by file, line and kind.
+func (p *Profile) Blocks() []Block {
+	blocks := make([]Block, 0, len(p.counts))
+	for block := range p.counts {
+		blocks = append(blocks, block)
+	}
+
+	sort.Slice(blocks, func(i, j int) bool {
+		a, b := blocks[i], blocks[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return kindOrder(a.Kind) < kindOrder(b.Kind)
+	})
+
+	return blocks
+}
+
+// Percentage returns how many of the blocks of the given kinds have run at
+// least once, or 100 when there are none.
+func (p *Profile) Percentage(file string, kinds ...Kind) float64 {
+	total, covered := 0, 0
+	for block, count := range p.counts {
+		if file != "" && block.File != file {
+			continue
+		}
+		for _, kind := range kinds {
+			if block.Kind == kind {
+				total++
+				if count > 0 {
+					covered++
+				}
+			}
+		}
+	}
+
+	if total == 0 {
+		return 100
+	}
+	return 100 * float64(covered) / float64(total)
+}
+
+func kindOrder(kind Kind) int {
+	switch kind {
+	case STATEMENT:
+		return 0
+	case THEN_BRANCH:
+		return 1
+	default:
+		return 2
+	}
+}
+
+func statementBlock(stmt ast.Statement) Block {
+	return newBlock(statementToken(stmt).Metadata, STATEMENT)
+}
+
+func branchBlock(ifexp *ast.IfExpression, consequence bool) Block {
+	if consequence {
+		return newBlock(ifexp.Token.Metadata, THEN_BRANCH)
+	}
+	return newBlock(ifexp.Token.Metadata, ELSE_BRANCH)
+}
+
+func newBlock(metadata token.Metadata, kind Kind) Block {
+	return Block{File: metadata.File, Line: metadata.Line, Kind: kind}
+}
+
+func statementToken(stmt ast.Statement) token.Token {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		return stmt.Token
+	case *ast.ReturnStatement:
+		return stmt.Token
+	case *ast.ExpressionStatement:
+		return stmt.Token
+	case *ast.FunctionDeclaration:
+		return stmt.Token
+	default:
+		return token.Token{}
+	}
+}
+
+func (p *Profile) register(block Block) {
+	if _, ok := p.counts[block]; !ok {
+		p.counts[block] = 0
+	}
+}
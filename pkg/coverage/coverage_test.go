@@ -0,0 +1,120 @@
+package coverage_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uesteibar/lainoa/pkg/coverage"
+	"github.com/uesteibar/lainoa/pkg/evaluator"
+	"github.com/uesteibar/lainoa/pkg/lexer"
+	"github.com/uesteibar/lainoa/pkg/object"
+	"github.com/uesteibar/lainoa/pkg/parser"
+)
+
+const program = `let classify = fun(n) {
+  if (n > 10) {
+    return "big"
+  }
+  if (n < 0) { "negative" } else { "small" }
+}
+
+let never = fun() { 1 }
+classify(20)
+classify(3)
+`
+
+func runWithCoverage(t *testing.T, file string) *coverage.Profile {
+	p := parser.New(lexer.New(program, file))
+	parsed := p.ParseProgram()
+	assert.Empty(t, p.Errors())
+
+	profile := coverage.New()
+	evaluator.SetCoverage(profile)
+	defer evaluator.SetCoverage(nil)
+
+	evaluator.Eval(parsed, object.NewEnvironment())
+	return profile
+}
+
+func TestProfile(t *testing.T) {
+	profile := runWithCoverage(t, "classify.ln")
+
+	tests := []struct {
+		line     int
+		kind     coverage.Kind
+		expected int
+	}{
+		{1, coverage.STATEMENT, 1},
+		{2, coverage.STATEMENT, 2},
+		{2, coverage.THEN_BRANCH, 1},
+		{2, coverage.ELSE_BRANCH, 1},
+		{3, coverage.STATEMENT, 1},
+		{5, coverage.STATEMENT, 2},
+		{5, coverage.THEN_BRANCH, 0},
+		{5, coverage.ELSE_BRANCH, 1},
+		{8, coverage.STATEMENT, 1},
+		{9, coverage.STATEMENT, 1},
+	}
+
+	for _, tt := range tests {
+		block := coverage.Block{File: "classify.ln", Line: tt.line, Kind: tt.kind}
+		assert.Equal(t, tt.expected, profile.Count(block), "line %d %s", tt.line, tt.kind)
+	}
+
+	// statements are counted per line, so the body of `never` that didn't
+	// run is counted together with the let on the same line
+	assert.Equal(t, 100.0, profile.Percentage("classify.ln", coverage.STATEMENT))
+	assert.Equal(t, 75.0, profile.Percentage("classify.ln", coverage.THEN_BRANCH, coverage.ELSE_BRANCH))
+}
+
+func TestWriteProfile(t *testing.T) {
+	profile := runWithCoverage(t, "classify.ln")
+
+	var out bytes.Buffer
+	assert.Nil(t, profile.WriteProfile(&out))
+
+	assert.Equal(t, `mode: count
+classify.ln:1 statement 1
+classify.ln:2 statement 2
+classify.ln:2 then 1
+classify.ln:2 else 1
+classify.ln:3 statement 1
+classify.ln:5 statement 2
+classify.ln:5 then 0
+classify.ln:5 else 1
+classify.ln:8 statement 1
+classify.ln:9 statement 1
+classify.ln:10 statement 1
+`, out.String())
+}
+
+func TestWriteReport(t *testing.T) {
+	file, err := ioutil.TempFile("", "lainoa")
+	assert.Nil(t, err)
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(program)
+	assert.Nil(t, err)
+	file.Close()
+
+	profile := runWithCoverage(t, file.Name())
+
+	var out bytes.Buffer
+	assert.Nil(t, profile.WriteReport(&out))
+
+	assert.Equal(t, file.Name()+`: 100.0% of statements, 75.0% of branches
+
+     1     1 | let classify = fun(n) {
+     2     2 |   if (n > 10) {  [then: 1, else: 1]
+     1     3 |     return "big"
+           4 |   }
+     2!    5 |   if (n < 0) { "negative" } else { "small" }  [then: 0, else: 1]
+           6 | }
+           7 | 
+     1     8 | let never = fun() { 1 }
+     1     9 | classify(20)
+     1    10 | classify(3)
+`, out.String())
+}
@@ -0,0 +1,91 @@
+package coverage
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// WriteProfile writes one line per block, with its position, kind and count:
+//
+//	mode: count
+//	examples/map.ln:3 statement 1
+//	examples/map.ln:4 then 0
+func (p *Profile) WriteProfile(w io.Writer) error {
+	out := bufio.NewWriter(w)
+
+	fmt.Fprintln(out, "mode: count")
+	for _, block := range p.Blocks() {
+		fmt.Fprintf(out, "%s:%d %s %d\n", block.File, block.Line, block.Kind, p.counts[block])
+	}
+
+	return out.Flush()
+}
+
+// Summary describes the coverage of statements and branches in all files.
+func (p *Profile) Summary() string {
+	return fmt.Sprintf("coverage: %.1f%% of statements, %.1f%% of branches",
+		p.Percentage("", STATEMENT), p.Percentage("", THEN_BRANCH, ELSE_BRANCH))
+}
+
+// WriteReport writes the source of every file in the profile, annotating
+// each line with how many times its statements ran and which `if` branches
+// were taken. Lines with code that never ran are marked with `!`.
+func (p *Profile) WriteReport(w io.Writer) error {
+	out := bufio.NewWriter(w)
+
+	for i, file := range p.files() {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		fmt.Fprintf(out, "%s: %.1f%% of statements, %.1f%% of branches\n\n", file,
+			p.Percentage(file, STATEMENT), p.Percentage(file, THEN_BRANCH, ELSE_BRANCH))
+
+		for n, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+			fmt.Fprintln(out, p.annotate(file, n+1, line))
+		}
+	}
+
+	return out.Flush()
+}
+
+func (p *Profile) annotate(file string, line int, source string) string {
+	count, marker, branches := "", " ", ""
+
+	statement := Block{File: file, Line: line, Kind: STATEMENT}
+	if n, ok := p.counts[statement]; ok {
+		count = fmt.Sprint(n)
+		if n == 0 {
+			marker = "!"
+		}
+	}
+
+	then := Block{File: file, Line: line, Kind: THEN_BRANCH}
+	if n, ok := p.counts[then]; ok {
+		otherwise := p.counts[Block{File: file, Line: line, Kind: ELSE_BRANCH}]
+		branches = fmt.Sprintf("  [then: %d, else: %d]", n, otherwise)
+		if n == 0 || otherwise == 0 {
+			marker = "!"
+		}
+	}
+
+	return fmt.Sprintf("%6s%s %4d | %s%s", count, marker, line, source, branches)
+}
+
+func (p *Profile) files() []string {
+	files := []string{}
+	for _, block := range p.Blocks() {
+		if len(files) == 0 || files[len(files)-1] != block.File {
+			files = append(files, block.File)
+		}
+	}
+
+	return files
+}
@@ -0,0 +1,456 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/uesteibar/lainoa/pkg/ast"
+	"github.com/uesteibar/lainoa/pkg/debugger"
+	"github.com/uesteibar/lainoa/pkg/evaluator"
+	"github.com/uesteibar/lainoa/pkg/lexer"
+	"github.com/uesteibar/lainoa/pkg/object"
+	"github.com/uesteibar/lainoa/pkg/parser"
+)
+
+// Lainoa programs run in a single thread.
+const threadID = 1
+
+// Server is a debug adapter for one program, speaking the Debug Adapter
+// Protocol. The program runs in its own goroutine, which blocks in the
+// session's OnStop while it's paused, so requests can inspect it safely.
+type Server struct {
+	reader *bufio.Reader
+
+	writeMu sync.Mutex
+	writer  io.Writer
+	seq     int
+
+	session    *debugger.Session
+	program    *ast.Program
+	args       []string
+	launched   bool
+	configured bool
+	started    bool
+	finished   chan struct{}
+
+	// paused and the variable handles are shared with the program goroutine
+	mu        sync.Mutex
+	paused    bool
+	resume    chan struct{}
+	variables []interface{} // environments, arrays and hashes, by handle - 1
+}
+
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{
+		reader:   bufio.NewReader(in),
+		writer:   out,
+		session:  debugger.New(false),
+		resume:   make(chan struct{}),
+		finished: make(chan struct{}),
+	}
+}
+
+// Serve handles requests until the client disconnects or the input ends.
+func (s *Server) Serve() error {
+	s.session.OnStop = s.stopped
+
+	for {
+		data, err := readMessage(s.reader)
+		if err == io.EOF {
+			s.terminate()
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(data, &req); err != nil {
+			return fmt.Errorf("invalid message: %s", err)
+		}
+		if req.Type != "request" {
+			continue
+		}
+
+		if s.handle(&req) {
+			return nil
+		}
+	}
+}
+
+// handle answers a request, returning whether the session is over.
+func (s *Server) handle(req *request) bool {
+	switch req.Command {
+	case "initialize":
+		s.respond(req, map[string]interface{}{
+			"supportsConfigurationDoneRequest": true,
+			"supportsTerminateRequest":         true,
+		})
+		s.send("initialized", nil)
+	case "launch":
+		s.launch(req)
+	case "setBreakpoints":
+		s.setBreakpoints(req)
+	case "setExceptionBreakpoints":
+		s.respond(req, nil)
+	case "configurationDone":
+		s.configured = true
+		s.respond(req, nil)
+		s.start()
+	case "threads":
+		s.respond(req, map[string]interface{}{
+			"threads": []thread{{ID: threadID, Name: "main"}},
+		})
+	case "continue":
+		s.step(req, s.session.Continue)
+	case "next":
+		s.step(req, s.session.StepOver)
+	case "stepIn":
+		s.step(req, s.session.StepIn)
+	case "stepOut":
+		s.step(req, s.session.StepOut)
+	case "stackTrace":
+		s.stackTrace(req)
+	case "scopes":
+		s.scopes(req)
+	case "variables":
+		s.variablesRequest(req)
+	case "evaluate":
+		s.evaluate(req)
+	case "terminate":
+		s.terminate()
+		s.respond(req, nil)
+	case "disconnect":
+		s.terminate()
+		s.respond(req, nil)
+		return true
+	default:
+		s.fail(req, fmt.Sprintf("unsupported request %q", req.Command))
+	}
+
+	return false
+}
+
+func (s *Server) launch(req *request) {
+	var args launchArguments
+	if err := json.Unmarshal(req.Arguments, &args); err != nil || args.Program == "" {
+		s.fail(req, "launch needs the path of the program to debug")
+		return
+	}
+
+	path, err := filepath.Abs(args.Program)
+	if err != nil {
+		s.fail(req, err.Error())
+		return
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		s.fail(req, fmt.Sprintf("can't read %s: %s", args.Program, err))
+		return
+	}
+
+	p := parser.New(lexer.New(string(data), path))
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		messages := []string{}
+		for _, err := range p.Errors() {
+			messages = append(messages, err.String())
+		}
+		s.fail(req, "parser errors:\n"+strings.Join(messages, "\n"))
+		return
+	}
+
+	s.program = program
+	s.args = args.Args
+	if args.StopOnEntry {
+		s.session.StopOnEntry()
+	}
+	s.launched = true
+	s.respond(req, nil)
+	s.start()
+}
+
+// start runs the program once it's launched and configured.
+func (s *Server) start() {
+	if !s.launched || !s.configured || s.started {
+		return
+	}
+	s.started = true
+
+	stdout := &outputWriter{server: s, category: "stdout"}
+	console := &outputWriter{server: s, category: "console"}
+	evaluator.SetOutput(stdout)
+
+	go func() {
+		status := debugger.Run(s.session, s.program, s.args, console)
+		s.send("exited", map[string]interface{}{"exitCode": status})
+		s.send("terminated", nil)
+		close(s.finished)
+	}()
+}
+
+// terminate stops the program, if it's running, and waits for it to end.
+func (s *Server) terminate() {
+	if !s.started {
+		return
+	}
+
+	s.mu.Lock()
+	s.session.Terminate()
+	paused := s.paused
+	s.paused = false
+	s.mu.Unlock()
+
+	if paused {
+		s.resume <- struct{}{}
+	}
+	<-s.finished
+}
+
+func (s *Server) setBreakpoints(req *request) {
+	var args setBreakpointsArguments
+	if err := json.Unmarshal(req.Arguments, &args); err != nil {
+		s.fail(req, err.Error())
+		return
+	}
+
+	lines := []int{}
+	breakpoints := []breakpoint{}
+	for _, bp := range args.Breakpoints {
+		lines = append(lines, bp.Line)
+		breakpoints = append(breakpoints, breakpoint{Verified: true, Line: bp.Line})
+	}
+	s.session.SetBreakpoints(args.Source.Path, lines)
+
+	s.respond(req, map[string]interface{}{"breakpoints": breakpoints})
+}
+
+// stopped runs in the program goroutine, which stays paused until a request
+// resumes it.
+func (s *Server) stopped(reason debugger.StopReason) {
+	s.mu.Lock()
+	s.paused = true
+	s.variables = nil
+	s.mu.Unlock()
+
+	s.send("stopped", map[string]interface{}{
+		"reason":            string(reason),
+		"threadId":          threadID,
+		"allThreadsStopped": true,
+	})
+
+	<-s.resume
+}
+
+func (s *Server) step(req *request, resume func()) {
+	s.mu.Lock()
+	if !s.paused {
+		s.mu.Unlock()
+		s.fail(req, "the program isn't paused")
+		return
+	}
+	resume()
+	s.paused = false
+	s.mu.Unlock()
+
+	if req.Command == "continue" {
+		s.respond(req, map[string]interface{}{"allThreadsContinued": true})
+	} else {
+		s.respond(req, nil)
+	}
+	s.resume <- struct{}{}
+}
+
+// whilePaused runs fn if the program is paused, failing the request if not.
+func (s *Server) whilePaused(req *request, fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.paused {
+		s.fail(req, "the program isn't paused")
+		return
+	}
+	fn()
+}
+
+// Frames are identified by their position in the stack, starting at 1.
+func (s *Server) stackTrace(req *request) {
+	s.whilePaused(req, func() {
+		frames := []stackFrame{}
+		for i, frame := range s.session.Frames() {
+			frames = append(frames, stackFrame{
+				ID:     i + 1,
+				Name:   frame.Name,
+				Source: &source{Name: filepath.Base(frame.Location.File), Path: frame.Location.File},
+				Line:   frame.Location.Line,
+				Column: 1,
+			})
+		}
+
+		s.respond(req, map[string]interface{}{"stackFrames": frames, "totalFrames": len(frames)})
+	})
+}
+
+// scopes returns one scope per environment, from the frame's own one out to
+// the global environment.
+func (s *Server) scopes(req *request) {
+	var args frameArguments
+	json.Unmarshal(req.Arguments, &args)
+
+	s.whilePaused(req, func() {
+		frames := s.session.Frames()
+		if args.FrameID < 1 || args.FrameID > len(frames) {
+			s.fail(req, fmt.Sprintf("there's no frame %d", args.FrameID))
+			return
+		}
+
+		scopes := []scope{}
+		for env := frames[args.FrameID-1].Env; env != nil; env = env.Outer() {
+			name := "Closure"
+			switch {
+			case env.Outer() == nil:
+				name = "Globals"
+			case len(scopes) == 0:
+				name = "Locals"
+			}
+			scopes = append(scopes, scope{Name: name, VariablesReference: s.reference(env)})
+		}
+
+		s.respond(req, map[string]interface{}{"scopes": scopes})
+	})
+}
+
+func (s *Server) variablesRequest(req *request) {
+	var args variablesArguments
+	json.Unmarshal(req.Arguments, &args)
+
+	s.whilePaused(req, func() {
+		if args.VariablesReference < 1 || args.VariablesReference > len(s.variables) {
+			s.fail(req, fmt.Sprintf("unknown variables reference %d", args.VariablesReference))
+			return
+		}
+
+		variables := []variable{}
+		switch container := s.variables[args.VariablesReference-1].(type) {
+		case *object.Environment:
+			bindings := container.Bindings()
+			names := make([]string, 0, len(bindings))
+			for name := range bindings {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				variables = append(variables, s.variable(name, bindings[name]))
+			}
+		case *object.Array:
+			for i, el := range container.Elements {
+				variables = append(variables, s.variable(strconv.Itoa(i), el))
+			}
+		case *object.Hash:
+			for _, pair := range container.Pairs() {
+				variables = append(variables, s.variable(pair.Key.Inspect(), pair.Value))
+			}
+		}
+
+		s.respond(req, map[string]interface{}{"variables": variables})
+	})
+}
+
+func (s *Server) evaluate(req *request) {
+	var args evaluateArguments
+	json.Unmarshal(req.Arguments, &args)
+
+	s.whilePaused(req, func() {
+		frame := args.FrameID - 1
+		if frame < 0 {
+			frame = 0
+		}
+
+		res := s.session.Evaluate(frame, args.Expression)
+		if err, ok := res.(*object.Error); ok {
+			s.fail(req, err.Message)
+			return
+		}
+
+		v := s.variable("", res)
+		s.respond(req, map[string]interface{}{
+			"result":             v.Value,
+			"type":               v.Type,
+			"variablesReference": v.VariablesReference,
+		})
+	})
+}
+
+func (s *Server) variable(name string, obj object.Object) variable {
+	v := variable{Name: name, Value: object.Describe(obj)}
+	if obj == nil {
+		return v
+	}
+
+	v.Type = string(obj.Type())
+	switch obj := obj.(type) {
+	case *object.Array:
+		if len(obj.Elements) > 0 {
+			v.VariablesReference = s.reference(obj)
+		}
+	case *object.Hash:
+		if obj.Len() > 0 {
+			v.VariablesReference = s.reference(obj)
+		}
+	}
+
+	return v
+}
+
+// reference returns a handle to something with variables in it, which is
+// valid until the program resumes. It must be called with mu locked.
+func (s *Server) reference(container interface{}) int {
+	s.variables = append(s.variables, container)
+	return len(s.variables)
+}
+
+func (s *Server) respond(req *request, body interface{}) {
+	s.write(&response{Type: "response", RequestSeq: req.Seq, Success: true, Command: req.Command, Body: body})
+}
+
+func (s *Server) fail(req *request, message string) {
+	s.write(&response{Type: "response", RequestSeq: req.Seq, Success: false, Command: req.Command, Message: message})
+}
+
+func (s *Server) send(name string, body interface{}) {
+	s.write(&event{Type: "event", Event: name, Body: body})
+}
+
+func (s *Server) write(msg interface{}) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	s.seq++
+	switch msg := msg.(type) {
+	case *response:
+		msg.Seq = s.seq
+	case *event:
+		msg.Seq = s.seq
+	}
+
+	writeMessage(s.writer, msg)
+}
+
+// outputWriter sends what the program prints as output events.
+type outputWriter struct {
+	server   *Server
+	category string
+}
+
+func (w *outputWriter) Write(data []byte) (int, error) {
+	w.server.send("output", map[string]interface{}{"category": w.category, "output": string(data)})
+	return len(data), nil
+}
@@ -0,0 +1,273 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uesteibar/lainoa/pkg/evaluator"
+)
+
+const program = `let double = fun(x) {
+  let y = x * 2
+  y
+}
+
+let a = 1
+puts(double(a))
+`
+
+type message struct {
+	Seq        int                    `json:"seq"`
+	Type       string                 `json:"type"`
+	RequestSeq int                    `json:"request_seq"`
+	Success    bool                   `json:"success"`
+	Command    string                 `json:"command"`
+	Message    string                 `json:"message"`
+	Event      string                 `json:"event"`
+	Body       map[string]interface{} `json:"body"`
+}
+
+type client struct {
+	t        *testing.T
+	in       *io.PipeWriter
+	messages chan message
+	seq      int
+	events   []message
+}
+
+// connect starts a server talking to a client through pipes. The client
+// reads every message as soon as it's sent, so the server never blocks.
+func connect(t *testing.T) (*client, chan error) {
+	inReader, inWriter := io.Pipe()
+	outReader, outWriter := io.Pipe()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- NewServer(inReader, outWriter).Serve()
+		outWriter.Close()
+	}()
+
+	c := &client{t: t, in: inWriter, messages: make(chan message, 100)}
+	go func() {
+		out := bufio.NewReader(outReader)
+		for {
+			data, err := readMessage(out)
+			if err != nil {
+				close(c.messages)
+				return
+			}
+
+			var msg message
+			json.Unmarshal(data, &msg)
+			c.messages <- msg
+		}
+	}()
+
+	return c, done
+}
+
+func (c *client) send(command string, arguments interface{}) {
+	c.seq++
+	data, _ := json.Marshal(arguments)
+	assert.NoError(c.t, writeMessage(c.in, map[string]interface{}{
+		"seq": c.seq, "type": "request", "command": command, "arguments": json.RawMessage(data),
+	}))
+}
+
+func (c *client) read() message {
+	msg, ok := <-c.messages
+	if !ok {
+		c.t.Fatal("the server closed the connection")
+	}
+
+	return msg
+}
+
+// request sends a request and returns its response, keeping the events
+// that come before it.
+func (c *client) request(command string, arguments interface{}) message {
+	c.send(command, arguments)
+
+	for {
+		msg := c.read()
+		if msg.Type == "event" {
+			c.events = append(c.events, msg)
+			continue
+		}
+
+		assert.Equal(c.t, c.seq, msg.RequestSeq)
+		assert.Equal(c.t, command, msg.Command)
+		return msg
+	}
+}
+
+// waitFor returns the next event with the given name, keeping the ones
+// before it.
+func (c *client) waitFor(name string) message {
+	for i, event := range c.events {
+		if event.Event == name {
+			c.events = append(c.events[:i], c.events[i+1:]...)
+			return event
+		}
+	}
+
+	for {
+		msg := c.read()
+		if msg.Type == "event" && msg.Event == name {
+			return msg
+		}
+		c.events = append(c.events, msg)
+	}
+}
+
+func (c *client) output() string {
+	out := ""
+	for _, event := range c.events {
+		if event.Event == "output" && event.Body["category"] == "stdout" {
+			out += event.Body["output"].(string)
+		}
+	}
+
+	return out
+}
+
+func writeProgram(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "lainoa-dap")
+	assert.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "double.ln")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(program), 0644))
+
+	return path
+}
+
+func TestDebugSession(t *testing.T) {
+	defer evaluator.SetOutput(os.Stdout)
+	path := writeProgram(t)
+	c, done := connect(t)
+
+	res := c.request("initialize", map[string]interface{}{"adapterID": "lainoa"})
+	assert.True(t, res.Success)
+	assert.Equal(t, true, res.Body["supportsConfigurationDoneRequest"])
+	c.waitFor("initialized")
+
+	assert.True(t, c.request("launch", map[string]interface{}{"program": path}).Success)
+
+	res = c.request("setBreakpoints", map[string]interface{}{
+		"source":      map[string]interface{}{"path": path},
+		"breakpoints": []map[string]interface{}{{"line": 3}},
+	})
+	assert.True(t, res.Success)
+	assert.Equal(t, []interface{}{map[string]interface{}{"verified": true, "line": float64(3)}}, res.Body["breakpoints"])
+
+	assert.True(t, c.request("configurationDone", nil).Success)
+
+	stopped := c.waitFor("stopped")
+	assert.Equal(t, "breakpoint", stopped.Body["reason"])
+
+	res = c.request("threads", nil)
+	assert.Equal(t, []interface{}{map[string]interface{}{"id": float64(1), "name": "main"}}, res.Body["threads"])
+
+	res = c.request("stackTrace", map[string]interface{}{"threadId": 1})
+	frames := res.Body["stackFrames"].([]interface{})
+	assert.Len(t, frames, 2)
+	top := frames[0].(map[string]interface{})
+	assert.Equal(t, "double", top["name"])
+	assert.Equal(t, float64(3), top["line"])
+	assert.Equal(t, path, top["source"].(map[string]interface{})["path"])
+	assert.Equal(t, float64(7), frames[1].(map[string]interface{})["line"])
+
+	res = c.request("scopes", map[string]interface{}{"frameId": 1})
+	scopes := res.Body["scopes"].([]interface{})
+	assert.Len(t, scopes, 2)
+	locals := scopes[0].(map[string]interface{})
+	assert.Equal(t, "Locals", locals["name"])
+	assert.Equal(t, "Globals", scopes[1].(map[string]interface{})["name"])
+
+	res = c.request("variables", map[string]interface{}{"variablesReference": locals["variablesReference"]})
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"name": "x", "value": "1", "type": "INTEGER", "variablesReference": float64(0)},
+		map[string]interface{}{"name": "y", "value": "2", "type": "INTEGER", "variablesReference": float64(0)},
+	}, res.Body["variables"])
+
+	res = c.request("evaluate", map[string]interface{}{"expression": "[x, y * 10]", "frameId": 1})
+	assert.True(t, res.Success)
+	assert.Equal(t, "[1, 20]", res.Body["result"])
+	res = c.request("variables", map[string]interface{}{"variablesReference": res.Body["variablesReference"]})
+	assert.Len(t, res.Body["variables"], 2)
+
+	res = c.request("evaluate", map[string]interface{}{"expression": "missing", "frameId": 1})
+	assert.False(t, res.Success)
+	assert.Contains(t, res.Message, "missing")
+
+	assert.True(t, c.request("continue", map[string]interface{}{"threadId": 1}).Success)
+	exited := c.waitFor("exited")
+	assert.Equal(t, float64(0), exited.Body["exitCode"])
+	c.waitFor("terminated")
+	assert.Equal(t, "2\n", c.output())
+
+	res = c.request("stackTrace", map[string]interface{}{"threadId": 1})
+	assert.False(t, res.Success)
+
+	assert.True(t, c.request("disconnect", nil).Success)
+	assert.NoError(t, <-done)
+}
+
+func TestStopOnEntryAndDisconnect(t *testing.T) {
+	defer evaluator.SetOutput(os.Stdout)
+	path := writeProgram(t)
+	c, done := connect(t)
+
+	c.request("initialize", nil)
+	c.request("launch", map[string]interface{}{"program": path, "stopOnEntry": true})
+	c.request("configurationDone", nil)
+
+	stopped := c.waitFor("stopped")
+	assert.Equal(t, "entry", stopped.Body["reason"])
+
+	res := c.request("stackTrace", map[string]interface{}{"threadId": 1})
+	frame := res.Body["stackFrames"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, float64(1), frame["line"])
+
+	assert.True(t, c.request("disconnect", nil).Success)
+	exited := c.waitFor("exited")
+	assert.Equal(t, float64(1), exited.Body["exitCode"])
+	assert.Empty(t, c.output())
+	assert.NoError(t, <-done)
+}
+
+func TestLaunchErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lainoa-dap")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	broken := filepath.Join(dir, "broken.ln")
+	assert.NoError(t, ioutil.WriteFile(broken, []byte("let = 1"), 0644))
+
+	tests := []struct {
+		arguments interface{}
+		expected  string
+	}{
+		{map[string]interface{}{}, "launch needs the path of the program to debug"},
+		{map[string]interface{}{"program": filepath.Join(dir, "missing.ln")}, "can't read"},
+		{map[string]interface{}{"program": broken}, "parser errors:"},
+	}
+
+	for _, tt := range tests {
+		c, done := connect(t)
+		res := c.request("launch", tt.arguments)
+		assert.False(t, res.Success)
+		assert.True(t, strings.HasPrefix(res.Message, tt.expected), res.Message)
+
+		c.in.Close()
+		assert.NoError(t, <-done)
+	}
+}
@@ -0,0 +1,186 @@
+package debugger
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uesteibar/lainoa/pkg/lexer"
+	"github.com/uesteibar/lainoa/pkg/parser"
+)
+
+const program = `let double = fun(x) {
+  let y = x * 2
+  y
+}
+
+let a = 1
+let b = double(a)
+b + double(b)
+`
+
+type stop struct {
+	reason StopReason
+	line   int
+	depth  int
+}
+
+// debug runs the program, calling step every time it stops to decide how
+// to go on, and returns where it stopped.
+func debug(t *testing.T, session *Session, step func(s *Session, stops int)) []stop {
+	return debugSource(t, program, session, step)
+}
+
+func debugSource(t *testing.T, source string, session *Session, step func(s *Session, stops int)) []stop {
+	p := parser.New(lexer.New(source, "double.ln"))
+	parsed := p.ParseProgram()
+	assert.Empty(t, p.Errors())
+
+	stops := []stop{}
+	session.OnStop = func(reason StopReason) {
+		frames := session.Frames()
+		stops = append(stops, stop{reason, frames[0].Location.Line, len(frames)})
+		step(session, len(stops))
+	}
+
+	var out bytes.Buffer
+	Run(session, parsed, nil, &out)
+
+	return stops
+}
+
+func TestStepping(t *testing.T) {
+	tests := []struct {
+		name     string
+		step     func(s *Session)
+		expected []stop
+	}{
+		{"step in", (*Session).StepIn, []stop{
+			{ENTRY, 1, 1}, {STEP, 6, 1}, {STEP, 7, 1}, {STEP, 2, 2}, {STEP, 3, 2}, {STEP, 8, 1}, {STEP, 2, 2}, {STEP, 3, 2},
+		}},
+		{"step over", (*Session).StepOver, []stop{
+			{ENTRY, 1, 1}, {STEP, 6, 1}, {STEP, 7, 1}, {STEP, 8, 1},
+		}},
+		{"continue", (*Session).Continue, []stop{
+			{ENTRY, 1, 1},
+		}},
+	}
+
+	for _, tt := range tests {
+		stops := debug(t, New(true), func(s *Session, _ int) { tt.step(s) })
+
+		assert.Equal(t, tt.expected, stops, tt.name)
+	}
+}
+
+func TestStepOut(t *testing.T) {
+	session := New(false)
+	session.SetBreakpoints("double.ln", []int{2})
+
+	stops := debug(t, session, func(s *Session, _ int) { s.StepOut() })
+
+	assert.Equal(t, []stop{{BREAKPOINT, 2, 2}, {STEP, 8, 1}, {BREAKPOINT, 2, 2}}, stops)
+}
+
+func TestBreakpoints(t *testing.T) {
+	session := New(false)
+	session.SetBreakpoints("double.ln", []int{3, 7})
+	session.AddBreakpoint("double.ln", 8)
+	session.RemoveBreakpoint("double.ln", 7)
+
+	stops := debug(t, session, func(s *Session, _ int) { s.Continue() })
+
+	assert.Equal(t, []stop{{BREAKPOINT, 3, 2}, {BREAKPOINT, 8, 1}, {BREAKPOINT, 3, 2}}, stops)
+	assert.Len(t, session.Breakpoints(), 2)
+}
+
+func TestBreakpointsInRepeatedCalls(t *testing.T) {
+	source := `let double = fun(x) {
+  x * 2
+}
+map([1, 2, 3], double)
+`
+	session := New(false)
+	session.SetBreakpoints("double.ln", []int{2})
+
+	stops := debugSource(t, source, session, func(s *Session, _ int) { s.Continue() })
+
+	assert.Equal(t, []stop{{BREAKPOINT, 2, 2}, {BREAKPOINT, 2, 2}, {BREAKPOINT, 2, 2}}, stops)
+}
+
+func TestEvaluateInFrames(t *testing.T) {
+	session := New(false)
+	session.SetBreakpoints("double.ln", []int{3})
+
+	results := []string{}
+	debug(t, session, func(s *Session, stops int) {
+		if stops == 1 {
+			results = append(results,
+				s.Evaluate(0, "x + y").Inspect(),
+				s.Evaluate(1, "a").Inspect(),
+				s.Evaluate(1, "x").Inspect(),
+				s.Evaluate(2, "x").Inspect(),
+				s.Evaluate(0, "let").Inspect(),
+			)
+		}
+		s.Continue()
+	})
+
+	assert.Equal(t, []string{
+		"3",
+		"1",
+		"ERROR: identifier not found: x",
+		"ERROR: there's no frame 2",
+		"ERROR: expected next token to be IDENT, got EOF instead",
+	}, results)
+}
+
+func TestTerminate(t *testing.T) {
+	session := New(true)
+	stops := debug(t, session, func(s *Session, _ int) { s.Terminate() })
+
+	assert.Len(t, stops, 1)
+	assert.True(t, session.IsTerminated())
+}
+
+func TestTerminal(t *testing.T) {
+	commands := []string{"b 3", "c", "bt", "env", "p x * 10", "f 1", "p a", "o", "", "q"}
+	var out bytes.Buffer
+	session := New(true)
+	NewTerminal(session, "double.ln", program, &out, func() (string, error) {
+		if len(commands) == 0 {
+			return "", io.EOF
+		}
+		command := commands[0]
+		commands = commands[1:]
+		return command, nil
+	})
+
+	p := parser.New(lexer.New(program, "double.ln"))
+	status := Run(session, p.ParseProgram(), nil, &out)
+
+	assert.Equal(t, 1, status)
+	assert.Equal(t, `Stopped at double.ln:1 (entry)
+>    1 | let double = fun(x) {
+Breakpoint set at double.ln:3
+Stopped at double.ln:3 (breakpoint)
+>    3 |   y
+> 0 double at double.ln:3
+  1 main at double.ln:7
+scope 0:
+  x = 1
+  y = 2
+global 1:
+  a = 1
+  double = fn double(x)
+10
+Frame 1: main at double.ln:7
+1
+Stopped at double.ln:8 (step)
+>    8 | b + double(b)
+Stopped at double.ln:3 (breakpoint)
+>    3 |   y
+Debugging session terminated
+`, out.String())
+}
@@ -0,0 +1,344 @@
+package debugger
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/uesteibar/lainoa/pkg/ast"
+	"github.com/uesteibar/lainoa/pkg/evaluator"
+	"github.com/uesteibar/lainoa/pkg/lexer"
+	"github.com/uesteibar/lainoa/pkg/object"
+	"github.com/uesteibar/lainoa/pkg/parser"
+	"github.com/uesteibar/lainoa/pkg/token"
+)
+
+type StopReason string
+
+const (
+	ENTRY      = StopReason("entry")
+	BREAKPOINT = StopReason("breakpoint")
+	STEP       = StopReason("step")
+)
+
+// Frame is a function call in progress, or the top level of the program.
+type Frame struct {
+	Name     string
+	Function *object.Function // nil for the top level
+	Location token.Metadata   // of the statement being evaluated
+	Env      *object.Environment
+}
+
+type mode int
+
+const (
+	running mode = iota
+	stepIn
+	stepOver
+	stepOut
+)
+
+type position struct {
+	file  string
+	line  int
+	depth int
+}
+
+// Session follows the evaluation of a program as its Tracer, stopping at
+// breakpoints and when stepping. Every time it stops, OnStop is called and
+// the program stays paused until it returns. Use Continue or one of the
+// step methods before returning to decide how the program goes on.
+type Session struct {
+	OnStop func(reason StopReason)
+
+	// breakpoints can be changed while the program runs
+	mu          sync.Mutex
+	breakpoints map[string]map[int]bool
+	paths       map[string]string // normalized file paths, by the original one
+	frames      []*Frame
+	mode        mode
+	modeDepth   int
+	last        position
+	entry       bool
+	evaluating  bool
+	terminated  bool
+}
+
+// New creates a session for a program. When stopOnEntry is set, the program
+// stops before its first statement.
+func New(stopOnEntry bool) *Session {
+	s := &Session{
+		breakpoints: make(map[string]map[int]bool),
+		paths:       make(map[string]string),
+		frames:      []*Frame{{Name: "main"}},
+	}
+	s.entry = stopOnEntry
+
+	return s
+}
+
+// SetBreakpoints replaces the breakpoints in file with the given lines.
+func (s *Session) SetBreakpoints(file string, lines []int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bps := make(map[int]bool, len(lines))
+	for _, line := range lines {
+		bps[line] = true
+	}
+
+	s.breakpoints[normalizePath(file)] = bps
+}
+
+// AddBreakpoint and RemoveBreakpoint return whether they changed anything.
+func (s *Session) AddBreakpoint(file string, line int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file = normalizePath(file)
+	if s.breakpoints[file] == nil {
+		s.breakpoints[file] = make(map[int]bool)
+	}
+	if s.breakpoints[file][line] {
+		return false
+	}
+
+	s.breakpoints[file][line] = true
+	return true
+}
+
+func (s *Session) RemoveBreakpoint(file string, line int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file = normalizePath(file)
+	if !s.breakpoints[file][line] {
+		return false
+	}
+
+	delete(s.breakpoints[file], line)
+	return true
+}
+
+func (s *Session) hasBreakpoint(file string, line int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.breakpoints) == 0 {
+		return false
+	}
+
+	path, ok := s.paths[file]
+	if !ok {
+		path = normalizePath(file)
+		s.paths[file] = path
+	}
+
+	return s.breakpoints[path][line]
+}
+
+// StopOnEntry makes the program stop before its first statement, when the
+// session wasn't created to do so.
+func (s *Session) StopOnEntry() {
+	s.entry = true
+}
+
+func (s *Session) Continue() {
+	s.mode = running
+}
+
+// StepIn stops at the next statement, inside the function being called if
+// there's one.
+func (s *Session) StepIn() {
+	s.mode = stepIn
+}
+
+// StepOver stops at the next statement of the current function, or of the
+// function it returns to.
+func (s *Session) StepOver() {
+	s.mode = stepOver
+	s.modeDepth = len(s.frames)
+}
+
+// StepOut stops at the next statement after the current function returns.
+func (s *Session) StepOut() {
+	s.mode = stepOut
+	s.modeDepth = len(s.frames)
+}
+
+// Terminate stops the program before its next statement.
+func (s *Session) Terminate() {
+	s.terminated = true
+}
+
+// Frames returns the calls in progress, starting with the innermost one.
+func (s *Session) Frames() []*Frame {
+	frames := make([]*Frame, len(s.frames))
+	for i, frame := range s.frames {
+		frames[len(s.frames)-1-i] = frame
+	}
+
+	return frames
+}
+
+// Evaluate parses and evaluates source in the environment of a frame, as
+// numbered by Frames. Breakpoints are ignored while doing it.
+func (s *Session) Evaluate(frame int, source string) object.Object {
+	frames := s.Frames()
+	if frame < 0 || frame >= len(frames) {
+		return object.NewError("there's no frame %d", frame)
+	}
+	env := frames[frame].Env
+	if env == nil {
+		return object.NewError("frame %d hasn't started yet", frame)
+	}
+
+	p := parser.New(lexer.New(source, "debugger"))
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		messages := []string{}
+		for _, err := range p.Errors() {
+			messages = append(messages, err.Message)
+		}
+		return object.NewError("%s", strings.Join(messages, ", "))
+	}
+
+	s.evaluating = true
+	defer func() { s.evaluating = false }()
+
+	return evaluator.Eval(program, env)
+}
+
+func (s *Session) Statement(stmt ast.Statement, env *object.Environment) *object.Error {
+	if s.evaluating {
+		return nil
+	}
+	if s.terminated {
+		return object.NewError("debugging session terminated")
+	}
+
+	frame := s.frames[len(s.frames)-1]
+	frame.Location = statementLocation(stmt)
+	frame.Env = env
+
+	current := position{file: frame.Location.File, line: frame.Location.Line, depth: len(s.frames)}
+	reason, stop := s.shouldStop(current)
+	s.last = current
+
+	if stop && s.OnStop != nil {
+		s.mode = running
+		s.OnStop(reason)
+	}
+	if s.terminated {
+		return object.NewError("debugging session terminated")
+	}
+
+	return nil
+}
+
+func (s *Session) shouldStop(current position) (StopReason, bool) {
+	switch {
+	case s.entry:
+		s.entry = false
+		return ENTRY, true
+	case s.mode == stepIn:
+		return STEP, true
+	case s.mode == stepOver && current.depth <= s.modeDepth:
+		return STEP, true
+	case s.mode == stepOut && current.depth < s.modeDepth:
+		return STEP, true
+	}
+
+	// a line with several statements only stops once
+	if current != s.last && s.hasBreakpoint(current.file, current.line) {
+		return BREAKPOINT, true
+	}
+
+	return "", false
+}
+
+func (s *Session) Call(fn *object.Function) {
+	if s.evaluating {
+		return
+	}
+
+	name := fn.Literal.Name
+	if name == "" {
+		name = "anonymous"
+	}
+
+	s.frames = append(s.frames, &Frame{
+		Name:     name,
+		Function: fn,
+		Location: fn.Literal.Token.Metadata,
+	})
+	// every call stops at its breakpoints, even when the last one stopped
+	// at the same place
+	s.last = position{}
+}
+
+func (s *Session) Return() {
+	if s.evaluating {
+		return
+	}
+
+	s.frames = s.frames[:len(s.frames)-1]
+	s.last = position{}
+}
+
+// IsTerminated tells whether Terminate has been called.
+func (s *Session) IsTerminated() bool {
+	return s.terminated
+}
+
+func statementLocation(stmt ast.Statement) token.Metadata {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		return stmt.Token.Metadata
+	case *ast.ReturnStatement:
+		return stmt.Token.Metadata
+	case *ast.ExpressionStatement:
+		return stmt.Token.Metadata
+	case *ast.FunctionDeclaration:
+		return stmt.Token.Metadata
+	default:
+		return token.Metadata{}
+	}
+}
+
+func normalizePath(file string) string {
+	if abs, err := filepath.Abs(file); err == nil {
+		return abs
+	}
+
+	return filepath.Clean(file)
+}
+
+// Breakpoint is a line where the program stops.
+type Breakpoint struct {
+	File string
+	Line int
+}
+
+// Breakpoints returns every breakpoint, sorted by file and line.
+func (s *Session) Breakpoints() []Breakpoint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bps := []Breakpoint{}
+	for file, lines := range s.breakpoints {
+		for line := range lines {
+			bps = append(bps, Breakpoint{File: file, Line: line})
+		}
+	}
+
+	sort.Slice(bps, func(i, j int) bool {
+		if bps[i].File != bps[j].File {
+			return bps[i].File < bps[j].File
+		}
+		return bps[i].Line < bps[j].Line
+	})
+
+	return bps
+}
@@ -0,0 +1,298 @@
+package debugger
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/chzyer/readline"
+	"github.com/uesteibar/lainoa/pkg/ast"
+	"github.com/uesteibar/lainoa/pkg/evaluator"
+	"github.com/uesteibar/lainoa/pkg/lexer"
+	"github.com/uesteibar/lainoa/pkg/object"
+	"github.com/uesteibar/lainoa/pkg/parser"
+)
+
+const PROMPT = "(debug) "
+
+const help = `Commands:
+
+	c, continue		run until the next breakpoint
+	s, step			step into the next statement, entering function calls
+	n, next			step over to the next statement in this function
+	o, out			step out of the current function
+	b, break [file:]line	set a breakpoint
+	clear [file:]line	remove a breakpoint
+	breakpoints		list the breakpoints
+	bt, stack		show the call stack
+	f, frame n		select the frame n of the stack for env and print
+	env			show the bindings visible from the selected frame
+	p, print expression	evaluate an expression in the selected frame
+	l, list			show the source around the current line
+	q, quit			stop the program and the debugger
+	h, help			show this help
+
+An empty line repeats the last command.`
+
+// Terminal drives a debugging session from commands typed in a prompt.
+type Terminal struct {
+	session *Session
+	out     io.Writer
+	file    string
+	source  []string
+	frame   int
+	last    string
+
+	readCommand func() (string, error)
+}
+
+func NewTerminal(session *Session, file string, source string, out io.Writer, readCommand func() (string, error)) *Terminal {
+	t := &Terminal{
+		session:     session,
+		out:         out,
+		file:        file,
+		source:      strings.Split(source, "\n"),
+		readCommand: readCommand,
+	}
+	session.OnStop = t.stop
+
+	return t
+}
+
+// Start debugs the program in filepath, stopping before its first
+// statement, and returns the exit status for the process.
+func Start(filepath string, args []string) int {
+	data, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error reading file", err)
+		return 1
+	}
+
+	p := parser.New(lexer.New(string(data), filepath))
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		fmt.Fprintln(os.Stderr, "Oops! Something is wrong here:")
+		fmt.Fprintln(os.Stderr, "  parser errors:")
+		for _, err := range p.Errors() {
+			fmt.Fprintln(os.Stderr, fmt.Sprintf("- %s\n", err.String()))
+		}
+		return 1
+	}
+
+	rl, err := readline.NewEx(&readline.Config{
+		Prompt:          PROMPT,
+		InterruptPrompt: "^C",
+		EOFPrompt:       "quit",
+	})
+	if err != nil {
+		panic(err)
+	}
+	defer rl.Close()
+
+	session := New(true)
+	NewTerminal(session, filepath, string(data), os.Stdout, rl.Readline)
+	fmt.Println("Debugging", filepath, "- type help to see the available commands")
+
+	return Run(session, program, args, os.Stdout)
+}
+
+// Run evaluates program under the session and reports how it ended,
+// returning the exit status for the process.
+func Run(session *Session, program *ast.Program, args []string, out io.Writer) int {
+	evaluator.SetArgs(args)
+	evaluator.SetTracer(session)
+	defer evaluator.SetTracer(nil)
+
+	evaluated := evaluator.Eval(program, object.NewEnvironment())
+
+	err, ok := evaluated.(*object.Error)
+	switch {
+	case session.IsTerminated():
+		fmt.Fprintln(out, "Debugging session terminated")
+		return 1
+	case ok && err.Exit:
+		fmt.Fprintf(out, "Program exited with status %d\n", err.ExitCode)
+		return err.ExitCode
+	case ok:
+		fmt.Fprintln(out, "Program failed:", err.Inspect())
+		return 1
+	default:
+		fmt.Fprintln(out, "Program finished")
+		return 0
+	}
+}
+
+// stop shows where the program stopped and runs commands until one of
+// them resumes it.
+func (t *Terminal) stop(reason StopReason) {
+	t.frame = 0
+	location := t.session.Frames()[0].Location
+	fmt.Fprintf(t.out, "Stopped at %s:%d (%s)\n", location.File, location.Line, reason)
+	t.printSource(location.File, location.Line, 0)
+
+	for {
+		line, err := t.readCommand()
+		if err != nil {
+			t.session.Terminate()
+			return
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			line = t.last
+		}
+		t.last = line
+
+		if t.execute(line) {
+			return
+		}
+	}
+}
+
+// execute runs a command, returning whether it resumes the program.
+func (t *Terminal) execute(line string) bool {
+	command, arg := line, ""
+	if i := strings.IndexAny(line, " \t"); i >= 0 {
+		command, arg = line[:i], strings.TrimSpace(line[i+1:])
+	}
+
+	switch command {
+	case "":
+		return false
+	case "c", "continue":
+		t.session.Continue()
+		return true
+	case "s", "step":
+		t.session.StepIn()
+		return true
+	case "n", "next":
+		t.session.StepOver()
+		return true
+	case "o", "out":
+		t.session.StepOut()
+		return true
+	case "q", "quit":
+		t.session.Terminate()
+		return true
+	case "b", "break":
+		t.setBreakpoint(arg, true)
+	case "clear":
+		t.setBreakpoint(arg, false)
+	case "breakpoints":
+		for _, bp := range t.session.Breakpoints() {
+			fmt.Fprintf(t.out, "%s:%d\n", bp.File, bp.Line)
+		}
+	case "bt", "stack":
+		t.printStack()
+	case "f", "frame":
+		t.selectFrame(arg)
+	case "env":
+		t.printEnv()
+	case "p", "print":
+		fmt.Fprintln(t.out, object.Describe(t.session.Evaluate(t.frame, arg)))
+	case "l", "list":
+		location := t.session.Frames()[t.frame].Location
+		t.printSource(location.File, location.Line, 5)
+	case "h", "help":
+		fmt.Fprintln(t.out, help)
+	default:
+		fmt.Fprintf(t.out, "Unknown command %q, type help to see the available ones\n", command)
+	}
+
+	return false
+}
+
+func (t *Terminal) setBreakpoint(arg string, add bool) {
+	file, line := t.file, arg
+	if i := strings.LastIndex(arg, ":"); i >= 0 {
+		file, line = arg[:i], arg[i+1:]
+	}
+
+	n, err := strconv.Atoi(line)
+	if err != nil || n < 1 {
+		fmt.Fprintf(t.out, "Invalid line %q, expected [file:]line\n", arg)
+		return
+	}
+
+	if add {
+		t.session.AddBreakpoint(file, n)
+		fmt.Fprintf(t.out, "Breakpoint set at %s:%d\n", file, n)
+	} else if t.session.RemoveBreakpoint(file, n) {
+		fmt.Fprintf(t.out, "Breakpoint cleared at %s:%d\n", file, n)
+	} else {
+		fmt.Fprintf(t.out, "There's no breakpoint at %s:%d\n", file, n)
+	}
+}
+
+func (t *Terminal) printStack() {
+	for i, frame := range t.session.Frames() {
+		marker := " "
+		if i == t.frame {
+			marker = ">"
+		}
+		fmt.Fprintf(t.out, "%s %d %s at %s:%d\n", marker, i, frame.Name, frame.Location.File, frame.Location.Line)
+	}
+}
+
+func (t *Terminal) selectFrame(arg string) {
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 0 || n >= len(t.session.Frames()) {
+		fmt.Fprintf(t.out, "Invalid frame %q, see the stack with bt\n", arg)
+		return
+	}
+
+	t.frame = n
+	frame := t.session.Frames()[n]
+	fmt.Fprintf(t.out, "Frame %d: %s at %s:%d\n", n, frame.Name, frame.Location.File, frame.Location.Line)
+}
+
+// printEnv shows the bindings of each environment, from the innermost
+// scope out to the global one.
+func (t *Terminal) printEnv() {
+	env := t.session.Frames()[t.frame].Env
+
+	for depth := 0; env != nil; depth++ {
+		scope := "scope"
+		if env.Outer() == nil {
+			scope = "global"
+		}
+		fmt.Fprintf(t.out, "%s %d:\n", scope, depth)
+
+		bindings := env.Bindings()
+		names := make([]string, 0, len(bindings))
+		for name := range bindings {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			fmt.Fprintf(t.out, "  %s = %s\n", name, object.Describe(bindings[name]))
+		}
+
+		env = env.Outer()
+	}
+}
+
+// printSource prints the line of the file with context lines around it.
+// Only the program being debugged has its source available.
+func (t *Terminal) printSource(file string, line int, context int) {
+	if file != t.file {
+		return
+	}
+
+	for n := line - context; n <= line+context; n++ {
+		if n < 1 || n > len(t.source) {
+			continue
+		}
+
+		marker := " "
+		if n == line {
+			marker = ">"
+		}
+		fmt.Fprintf(t.out, "%s %4d | %s\n", marker, n, t.source[n-1])
+	}
+}
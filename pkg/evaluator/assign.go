@@ -3,13 +3,83 @@ package evaluator
 import (
 	"github.com/uesteibar/lainoa/pkg/ast"
 	"github.com/uesteibar/lainoa/pkg/object"
+	"github.com/uesteibar/lainoa/pkg/token"
 )
 
+// compoundOperators maps compound assignments to the infix operation they
+// perform before assigning, so `a += 1` behaves like `a = a + 1`.
+var compoundOperators = map[token.TokenType]string{
+	token.PLUS_ASSIGN:     token.PLUS,
+	token.MINUS_ASSIGN:    token.MINUS,
+	token.ASTERISK_ASSIGN: token.ASTERISK,
+	token.SLASH_ASSIGN:    token.SLASH,
+}
+
 func evalAssign(assign *ast.AssignExpression, env *object.Environment) object.Object {
 	val := Eval(assign.Value, env)
 	if object.IsError(val) {
 		return val
 	}
 
+	if operator, ok := compoundOperators[assign.Token.Type]; ok {
+		current := evalIdentifier(assign.Name, env)
+		if object.IsError(current) {
+			return current
+		}
+
+		val = evalInfixOperation(current, operator, val)
+		if object.IsError(val) {
+			return val
+		}
+	}
+
 	return env.Rebind(assign.Name.Value, val)
 }
+
+func evalIndexAssign(assign *ast.IndexAssignExpression, env *object.Environment) object.Object {
+	left := Eval(assign.Target.Left, env)
+	if object.IsError(left) {
+		return left
+	}
+	i := Eval(assign.Target.Index, env)
+	if object.IsError(i) {
+		return i
+	}
+	val := Eval(assign.Value, env)
+	if object.IsError(val) {
+		return val
+	}
+
+	switch left := left.(type) {
+	case *object.Array:
+		return evalArrayIndexAssign(left, i, assign.Token.Type, val)
+	case *object.Hash:
+		return evalHashIndexAssign(left, i, compoundOperators[assign.Token.Type], val)
+	default:
+		return object.NewError("type %s doesn't support index assignment", left.Type())
+	}
+}
+
+// evalArrayIndexAssign updates the array in place, so every binding holding
+// the same array sees the change.
+func evalArrayIndexAssign(array *object.Array, i object.Object, assignment token.TokenType, val object.Object) object.Object {
+	index, ok := i.(*object.Integer)
+	if !ok {
+		return object.NewError("expected %s as index for array, got %s", object.INTEGER_OBJECT, i.Type())
+	}
+
+	idx, ok := resolveIndex(index.Value, len(array.Elements))
+	if !ok {
+		return object.NewError("index %s out of range for array of length %d", index.Inspect(), len(array.Elements))
+	}
+
+	if operator, ok := compoundOperators[assignment]; ok {
+		val = evalInfixOperation(array.Elements[idx], operator, val)
+		if object.IsError(val) {
+			return val
+		}
+	}
+
+	array.Elements[idx] = val
+	return val
+}
@@ -2,7 +2,9 @@ package evaluator
 
 import (
 	"fmt"
-	"strconv"
+	"math/big"
+	"sort"
+	"unicode/utf8"
 
 	"github.com/uesteibar/lainoa/pkg/object"
 )
@@ -16,9 +18,11 @@ var builtins = map[string]*object.Builtin{
 			}
 			switch arg := args[0].(type) {
 			case *object.String:
-				return &object.Integer{Value: int64(len(arg.Value))}
+				return &object.Integer{Value: int64(utf8.RuneCountInString(arg.Value))}
 			case *object.Array:
 				return &object.Integer{Value: int64(len(arg.Elements))}
+			case *object.Hash:
+				return &object.Integer{Value: int64(arg.Len())}
 
 			default:
 				return object.NewError("argument to `len` not supported, got %s", arg.Type())
@@ -35,7 +39,7 @@ var builtins = map[string]*object.Builtin{
 			case *object.String:
 				return arg
 			case *object.Integer:
-				return &object.String{Value: strconv.Itoa(int(arg.Value))}
+				return &object.String{Value: arg.Inspect()}
 			default:
 				return object.NewError("argument to `to_string` not supported, got %s", arg.Type())
 			}
@@ -50,16 +54,16 @@ var builtins = map[string]*object.Builtin{
 
 			switch arg := args[0].(type) {
 			case *object.String:
-				fmt.Println(arg.Value)
+				fmt.Fprintln(output, arg.Value)
 				return arg
 			case *object.Integer:
-				fmt.Println(arg.Value)
+				fmt.Fprintln(output, arg.Inspect())
 				return arg
 			case *object.Boolean:
-				fmt.Println(arg.Value)
+				fmt.Fprintln(output, arg.Value)
 				return arg
 			case *object.Nil:
-				fmt.Println("nil")
+				fmt.Fprintln(output, "nil")
 				return arg
 			default:
 				return object.NewError("argument to `puts` not supported, got %s", arg.Type())
@@ -106,6 +110,25 @@ var builtins = map[string]*object.Builtin{
 			}
 		},
 	},
+	"keys": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return object.NewError("wrong number of arguments. got=%d, want=1",
+					len(args))
+			}
+			hash, ok := args[0].(*object.Hash)
+			if !ok {
+				return object.NewError("argument to `keys` must be HASH, got %s", args[0].Type())
+			}
+
+			keys := []object.Object{}
+			for _, pair := range hash.Pairs() {
+				keys = append(keys, pair.Key)
+			}
+
+			return &object.Array{Elements: keys}
+		},
+	},
 	"rest": &object.Builtin{
 		Fn: func(args ...object.Object) object.Object {
 			if len(args) != 1 {
@@ -129,3 +152,484 @@ var builtins = map[string]*object.Builtin{
 		},
 	},
 }
+
+// Higher order builtins call back into the evaluator, which looks builtins up,
+// so they're registered on init to avoid an initialization cycle.
+func init() {
+	builtins["map"] = &object.Builtin{Fn: builtinMap}
+	builtins["filter"] = &object.Builtin{Fn: builtinFilter}
+	builtins["reduce"] = &object.Builtin{Fn: builtinReduce}
+	builtins["each"] = &object.Builtin{Fn: builtinEach}
+	builtins["find"] = &object.Builtin{Fn: builtinFind}
+	builtins["any"] = &object.Builtin{Fn: builtinAny}
+	builtins["all"] = &object.Builtin{Fn: builtinAll}
+	builtins["sort"] = &object.Builtin{Fn: builtinSort}
+	builtins["sort_by"] = &object.Builtin{Fn: builtinSortBy}
+	builtins["zip"] = &object.Builtin{Fn: builtinZip}
+	builtins["flatten"] = &object.Builtin{Fn: builtinFlatten}
+	builtins["uniq"] = &object.Builtin{Fn: builtinUniq}
+	builtins["range"] = &object.Builtin{Fn: builtinRange}
+}
+
+// Builtins returns the names of every builtin function, sorted.
+func Builtins() []string {
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func builtinMap(args ...object.Object) object.Object {
+	arr, fn, err := arrayAndFunctionArgs("map", args)
+	if err != nil {
+		return err
+	}
+
+	elements := make([]object.Object, len(arr.Elements))
+	for i, el := range arr.Elements {
+		res := applyFunction(fn, []object.Object{el})
+		if object.IsError(res) {
+			return res
+		}
+		elements[i] = res
+	}
+
+	return &object.Array{Elements: elements}
+}
+
+func builtinFilter(args ...object.Object) object.Object {
+	arr, fn, err := arrayAndFunctionArgs("filter", args)
+	if err != nil {
+		return err
+	}
+
+	elements := []object.Object{}
+	for _, el := range arr.Elements {
+		res := applyFunction(fn, []object.Object{el})
+		if object.IsError(res) {
+			return res
+		}
+		if isTruthy(res) {
+			elements = append(elements, el)
+		}
+	}
+
+	return &object.Array{Elements: elements}
+}
+
+func builtinReduce(args ...object.Object) object.Object {
+	if len(args) != 3 {
+		return object.NewError("wrong number of arguments. got=%d, want=3",
+			len(args))
+	}
+	arr, ok := args[0].(*object.Array)
+	if !ok {
+		return object.NewError("first argument to `reduce` must be ARRAY, got %s", args[0].Type())
+	}
+	if !isCallable(args[2]) {
+		return object.NewError("third argument to `reduce` must be FUNCTION, got %s", args[2].Type())
+	}
+
+	acc := args[1]
+	for _, el := range arr.Elements {
+		acc = applyFunction(args[2], []object.Object{acc, el})
+		if object.IsError(acc) {
+			return acc
+		}
+	}
+
+	return acc
+}
+
+func builtinEach(args ...object.Object) object.Object {
+	arr, fn, err := arrayAndFunctionArgs("each", args)
+	if err != nil {
+		return err
+	}
+
+	for _, el := range arr.Elements {
+		res := applyFunction(fn, []object.Object{el})
+		if object.IsError(res) {
+			return res
+		}
+	}
+
+	return arr
+}
+
+func builtinFind(args ...object.Object) object.Object {
+	arr, fn, err := arrayAndFunctionArgs("find", args)
+	if err != nil {
+		return err
+	}
+
+	for _, el := range arr.Elements {
+		res := applyFunction(fn, []object.Object{el})
+		if object.IsError(res) {
+			return res
+		}
+		if isTruthy(res) {
+			return el
+		}
+	}
+
+	return NIL
+}
+
+func builtinAny(args ...object.Object) object.Object {
+	arr, fn, err := arrayAndFunctionArgs("any", args)
+	if err != nil {
+		return err
+	}
+
+	for _, el := range arr.Elements {
+		res := applyFunction(fn, []object.Object{el})
+		if object.IsError(res) {
+			return res
+		}
+		if isTruthy(res) {
+			return TRUE
+		}
+	}
+
+	return FALSE
+}
+
+func builtinAll(args ...object.Object) object.Object {
+	arr, fn, err := arrayAndFunctionArgs("all", args)
+	if err != nil {
+		return err
+	}
+
+	for _, el := range arr.Elements {
+		res := applyFunction(fn, []object.Object{el})
+		if object.IsError(res) {
+			return res
+		}
+		if !isTruthy(res) {
+			return FALSE
+		}
+	}
+
+	return TRUE
+}
+
+func builtinSort(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return object.NewError("wrong number of arguments. got=%d, want=1",
+			len(args))
+	}
+	arr, ok := args[0].(*object.Array)
+	if !ok {
+		return object.NewError("argument to `sort` must be ARRAY, got %s", args[0].Type())
+	}
+
+	return sortArray(arr.Elements, arr.Elements)
+}
+
+func builtinSortBy(args ...object.Object) object.Object {
+	arr, fn, err := arrayAndFunctionArgs("sort_by", args)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]object.Object, len(arr.Elements))
+	for i, el := range arr.Elements {
+		res := applyFunction(fn, []object.Object{el})
+		if object.IsError(res) {
+			return res
+		}
+		keys[i] = res
+	}
+
+	return sortArray(arr.Elements, keys)
+}
+
+// sortArray returns a new array with the elements sorted by their keys, in a
+// stable way, so elements with equal keys keep their order.
+func sortArray(elements []object.Object, keys []object.Object) object.Object {
+	// all keys must be comparable with each other, which is the case as long
+	// as they're comparable with the first one
+	for i := 1; i < len(keys); i++ {
+		if _, err := lessThan(keys[0], keys[i]); err != nil {
+			return err
+		}
+	}
+
+	indexes := make([]int, len(elements))
+	for i := range indexes {
+		indexes[i] = i
+	}
+
+	sort.SliceStable(indexes, func(i, j int) bool {
+		less, _ := lessThan(keys[indexes[i]], keys[indexes[j]])
+		return less
+	})
+
+	sorted := make([]object.Object, len(elements))
+	for i, idx := range indexes {
+		sorted[i] = elements[idx]
+	}
+
+	return &object.Array{Elements: sorted}
+}
+
+func lessThan(left object.Object, right object.Object) (bool, *object.Error) {
+	switch left := left.(type) {
+	case *object.Integer:
+		if right, ok := right.(*object.Integer); ok {
+			return left.Cmp(right) < 0, nil
+		}
+	case *object.String:
+		if right, ok := right.(*object.String); ok {
+			return left.Value < right.Value, nil
+		}
+	}
+
+	return false, object.NewError("can't compare %s with %s", left.Type(), right.Type())
+}
+
+func builtinZip(args ...object.Object) object.Object {
+	if len(args) < 2 {
+		return object.NewError("wrong number of arguments. got=%d, want at least 2",
+			len(args))
+	}
+
+	arrays := make([]*object.Array, len(args))
+	length := -1
+	for i, arg := range args {
+		arr, ok := arg.(*object.Array)
+		if !ok {
+			return object.NewError("arguments to `zip` must be ARRAY, got %s", arg.Type())
+		}
+		if length == -1 || len(arr.Elements) < length {
+			length = len(arr.Elements)
+		}
+		arrays[i] = arr
+	}
+
+	zipped := make([]object.Object, length)
+	for i := range zipped {
+		tuple := make([]object.Object, len(arrays))
+		for j, arr := range arrays {
+			tuple[j] = arr.Elements[i]
+		}
+		zipped[i] = &object.Array{Elements: tuple}
+	}
+
+	return &object.Array{Elements: zipped}
+}
+
+func builtinFlatten(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return object.NewError("wrong number of arguments. got=%d, want=1",
+			len(args))
+	}
+	arr, ok := args[0].(*object.Array)
+	if !ok {
+		return object.NewError("argument to `flatten` must be ARRAY, got %s", args[0].Type())
+	}
+
+	flat, err := flattenElements([]object.Object{}, arr, map[*object.Array]bool{})
+	if err != nil {
+		return err
+	}
+
+	return &object.Array{Elements: flat}
+}
+
+// flattenElements appends the elements of arr to flat, flattening the
+// arrays in it. inside has the arrays being flattened, as one that contains
+// itself would never end.
+func flattenElements(flat []object.Object, arr *object.Array, inside map[*object.Array]bool) ([]object.Object, *object.Error) {
+	if inside[arr] {
+		return nil, object.NewError("can't flatten an array that contains itself")
+	}
+	inside[arr] = true
+	defer delete(inside, arr)
+
+	for _, el := range arr.Elements {
+		if nested, ok := el.(*object.Array); ok {
+			var err *object.Error
+			if flat, err = flattenElements(flat, nested, inside); err != nil {
+				return nil, err
+			}
+		} else {
+			flat = append(flat, el)
+		}
+	}
+
+	return flat, nil
+}
+
+func builtinUniq(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return object.NewError("wrong number of arguments. got=%d, want=1",
+			len(args))
+	}
+	arr, ok := args[0].(*object.Array)
+	if !ok {
+		return object.NewError("argument to `uniq` must be ARRAY, got %s", args[0].Type())
+	}
+
+	elements := []object.Object{}
+	for _, el := range arr.Elements {
+		seen := false
+		for _, kept := range elements {
+			if objectsEqual(el, kept) {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			elements = append(elements, el)
+		}
+	}
+
+	return &object.Array{Elements: elements}
+}
+
+// objectsEqual compares integers, strings, booleans and nil by value, and
+// arrays and hashes element by element. Anything else is only equal to itself.
+func objectsEqual(left object.Object, right object.Object) bool {
+	return deepEqual(left, right, map[[2]object.Object]bool{})
+}
+
+// deepEqual is objectsEqual for arrays and hashes that may contain themselves.
+// The pairs in comparing are being compared already, so they're taken as
+// equal when they're found again, unless something else differs.
+func deepEqual(left object.Object, right object.Object, comparing map[[2]object.Object]bool) bool {
+	switch left := left.(type) {
+	case *object.Integer:
+		right, ok := right.(*object.Integer)
+		return ok && left.Cmp(right) == 0
+	case *object.String:
+		right, ok := right.(*object.String)
+		return ok && left.Value == right.Value
+	case *object.Boolean:
+		right, ok := right.(*object.Boolean)
+		return ok && left.Value == right.Value
+	case *object.Nil:
+		_, ok := right.(*object.Nil)
+		return ok
+	case *object.Array:
+		right, ok := right.(*object.Array)
+		if !ok || len(left.Elements) != len(right.Elements) {
+			return false
+		}
+		pair := [2]object.Object{left, right}
+		if comparing[pair] {
+			return true
+		}
+		comparing[pair] = true
+		defer delete(comparing, pair)
+
+		for i := range left.Elements {
+			if !deepEqual(left.Elements[i], right.Elements[i], comparing) {
+				return false
+			}
+		}
+		return true
+	case *object.Hash:
+		right, ok := right.(*object.Hash)
+		if !ok || left.Len() != right.Len() {
+			return false
+		}
+		pair := [2]object.Object{left, right}
+		if comparing[pair] {
+			return true
+		}
+		comparing[pair] = true
+		defer delete(comparing, pair)
+
+		for _, pair := range left.Pairs() {
+			value, ok := right.Get(pair.Key.(object.Hashable))
+			if !ok || !deepEqual(pair.Value, value, comparing) {
+				return false
+			}
+		}
+		return true
+	default:
+		return left == right
+	}
+}
+
+func builtinRange(args ...object.Object) object.Object {
+	if len(args) < 1 || len(args) > 3 {
+		return object.NewError("wrong number of arguments. got=%d, want=1..3",
+			len(args))
+	}
+
+	bounds := make([]int64, len(args))
+	for i, arg := range args {
+		integer, ok := arg.(*object.Integer)
+		if !ok {
+			return object.NewError("arguments to `range` must be INTEGER, got %s", arg.Type())
+		}
+		if integer.Big != nil {
+			return object.NewError("arguments to `range` are too big, got %s", integer.Inspect())
+		}
+		bounds[i] = integer.Value
+	}
+
+	start, end, step := int64(0), bounds[0], int64(1)
+	if len(bounds) > 1 {
+		start, end = bounds[0], bounds[1]
+	}
+	if len(bounds) > 2 {
+		step = bounds[2]
+	}
+	if step == 0 {
+		return object.NewError("step for `range` can't be 0")
+	}
+
+	count := rangeLength(start, end, step)
+	if count.Cmp(big.NewInt(maxRangeLength)) > 0 {
+		return object.NewError("`range` can't have more than %d elements, got %s", maxRangeLength, count)
+	}
+
+	// every element is between start and end, so they can't overflow
+	elements := make([]object.Object, count.Int64())
+	for i := range elements {
+		elements[i] = &object.Integer{Value: start + int64(i)*step}
+	}
+
+	return &object.Array{Elements: elements}
+}
+
+// maxRangeLength is the most elements range builds, so a typo in a bound
+// fails instead of exhausting the memory.
+const maxRangeLength = 1 << 24
+
+// rangeLength counts the elements from start to end, without end, going
+// step by step. It works with big.Ints, as end - start can overflow.
+func rangeLength(start int64, end int64, step int64) *big.Int {
+	if (step > 0 && start >= end) || (step < 0 && start <= end) {
+		return new(big.Int)
+	}
+
+	distance := new(big.Int).Sub(big.NewInt(end), big.NewInt(start))
+	distance.Abs(distance)
+	stride := new(big.Int).Abs(big.NewInt(step))
+	// rounded up, as start is always included
+	return distance.Add(distance, stride).Sub(distance, big.NewInt(1)).Quo(distance, stride)
+}
+
+func arrayAndFunctionArgs(name string, args []object.Object) (*object.Array, object.Object, *object.Error) {
+	if len(args) != 2 {
+		return nil, nil, object.NewError("wrong number of arguments. got=%d, want=2",
+			len(args))
+	}
+	arr, ok := args[0].(*object.Array)
+	if !ok {
+		return nil, nil, object.NewError("first argument to `%s` must be ARRAY, got %s", name, args[0].Type())
+	}
+	if !isCallable(args[1]) {
+		return nil, nil, object.NewError("second argument to `%s` must be FUNCTION, got %s", name, args[1].Type())
+	}
+
+	return arr, args[1], nil
+}
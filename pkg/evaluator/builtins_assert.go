@@ -0,0 +1,105 @@
+package evaluator
+
+import (
+	"strings"
+
+	"github.com/uesteibar/lainoa/pkg/object"
+)
+
+func init() {
+	builtins["assert"] = &object.Builtin{Fn: builtinAssert}
+	builtins["assert_eq"] = &object.Builtin{Fn: builtinAssertEq}
+	builtins["assert_error"] = &object.Builtin{Fn: builtinAssertError}
+}
+
+func builtinAssert(args ...object.Object) object.Object {
+	if len(args) < 1 || len(args) > 2 {
+		return object.NewError("wrong number of arguments. got=%d, want=1..2",
+			len(args))
+	}
+	condition, ok := args[0].(*object.Boolean)
+	if !ok {
+		return object.NewError("first argument to `assert` must be BOOLEAN, got %s", args[0].Type())
+	}
+	message, err := assertionMessage("assert", args, 1)
+	if err != nil {
+		return err
+	}
+
+	if !condition.Value {
+		return object.NewError("assertion failed%s", message)
+	}
+
+	return NIL
+}
+
+// builtinAssertEq takes the actual value first, so that it reads well in
+// pipes: `sum(numbers) |> assert_eq(6)`.
+func builtinAssertEq(args ...object.Object) object.Object {
+	if len(args) < 2 || len(args) > 3 {
+		return object.NewError("wrong number of arguments. got=%d, want=2..3",
+			len(args))
+	}
+	message, err := assertionMessage("assert_eq", args, 2)
+	if err != nil {
+		return err
+	}
+
+	actual, expected := args[0], args[1]
+	if !objectsEqual(actual, expected) {
+		return object.NewError("assertion failed%s: expected %s, got %s",
+			message, expected.Inspect(), actual.Inspect())
+	}
+
+	return NIL
+}
+
+// builtinAssertError calls the given function and checks that it fails.
+// It takes a function because errors stop the evaluation as soon as they
+// happen, so they can't be passed around as values.
+func builtinAssertError(args ...object.Object) object.Object {
+	if len(args) < 1 || len(args) > 2 {
+		return object.NewError("wrong number of arguments. got=%d, want=1..2",
+			len(args))
+	}
+	if !isCallable(args[0]) {
+		return object.NewError("first argument to `assert_error` must be a function, got %s", args[0].Type())
+	}
+	var expected string
+	if len(args) == 2 {
+		str, ok := args[1].(*object.String)
+		if !ok {
+			return object.NewError("second argument to `assert_error` must be STRING, got %s", args[1].Type())
+		}
+		expected = str.Value
+	}
+
+	res := applyFunction(args[0], []object.Object{})
+	err, ok := res.(*object.Error)
+	if ok && err.Exit {
+		return err
+	}
+	if !ok {
+		return object.NewError("assertion failed: expected an error, got %s", res.Inspect())
+	}
+	if !strings.Contains(err.Message, expected) {
+		return object.NewError("assertion failed: expected an error containing %q, got %q",
+			expected, err.Message)
+	}
+
+	return NIL
+}
+
+func assertionMessage(name string, args []object.Object, position int) (string, *object.Error) {
+	if len(args) <= position {
+		return "", nil
+	}
+
+	message, ok := args[position].(*object.String)
+	if !ok {
+		return "", object.NewError("%s argument to `%s` must be STRING, got %s",
+			ordinals[position], name, args[position].Type())
+	}
+
+	return ": " + message.Value, nil
+}
@@ -0,0 +1,278 @@
+package evaluator
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/uesteibar/lainoa/pkg/object"
+)
+
+// Filesystem decides what the filesystem builtins are allowed to touch.
+// Only paths inside one of the Roots are accessible, so the zero value
+// disables filesystem access completely.
+type Filesystem struct {
+	Roots    []string
+	ReadOnly bool
+}
+
+var filesystem Filesystem
+
+// SetFilesystem configures the filesystem access for every program
+// evaluated from now on.
+func SetFilesystem(fs Filesystem) error {
+	roots := make([]string, len(fs.Roots))
+	for i, root := range fs.Roots {
+		abs, err := filepath.Abs(root)
+		if err != nil {
+			return err
+		}
+		if abs, err = filepath.EvalSymlinks(abs); err != nil {
+			return err
+		}
+		roots[i] = abs
+	}
+
+	filesystem = Filesystem{Roots: roots, ReadOnly: fs.ReadOnly}
+	return nil
+}
+
+func init() {
+	builtins["read_file"] = &object.Builtin{Fn: builtinReadFile}
+	builtins["write_file"] = &object.Builtin{Fn: builtinWriteFile}
+	builtins["append_file"] = &object.Builtin{Fn: builtinAppendFile}
+	builtins["list_dir"] = &object.Builtin{Fn: builtinListDir}
+	builtins["exists"] = &object.Builtin{Fn: builtinExists}
+	builtins["remove"] = &object.Builtin{Fn: builtinRemove}
+}
+
+func builtinReadFile(args ...object.Object) object.Object {
+	path, err := pathArg("read_file", args, 1, false)
+	if err != nil {
+		return err
+	}
+
+	data, readErr := ioutil.ReadFile(path)
+	if readErr != nil {
+		return fileError("read_file", args[0], readErr)
+	}
+
+	return &object.String{Value: string(data)}
+}
+
+func builtinWriteFile(args ...object.Object) object.Object {
+	return writeFile("write_file", args, os.O_WRONLY)
+}
+
+func builtinAppendFile(args ...object.Object) object.Object {
+	return writeFile("append_file", args, os.O_WRONLY|os.O_APPEND)
+}
+
+func writeFile(name string, args []object.Object, flag int) object.Object {
+	path, err := pathArg(name, args, 2, true)
+	if err != nil {
+		return err
+	}
+	content, ok := args[1].(*object.String)
+	if !ok {
+		return object.NewError("second argument to `%s` must be STRING, got %s", name, args[1].Type())
+	}
+
+	file, openErr := openWritable(path, flag)
+	if openErr == errOutsideRoots {
+		return object.NewError("access to %s is outside the allowed directories", args[0].Inspect())
+	}
+	if openErr != nil {
+		return fileError(name, args[0], openErr)
+	}
+	defer file.Close()
+	if flag&os.O_APPEND == 0 {
+		if truncErr := file.Truncate(0); truncErr != nil {
+			return fileError(name, args[0], truncErr)
+		}
+	}
+
+	if _, writeErr := file.WriteString(content.Value); writeErr != nil {
+		return fileError(name, args[0], writeErr)
+	}
+
+	return NIL
+}
+
+// openWritable opens path without following a symlink at its end, and only
+// creates the file when there's nothing there yet, so opening it doesn't
+// change an existing file even if path was swapped for a symlink after it
+// was checked. The opened file is checked again before it's returned, and
+// nothing is truncated or written until then.
+func openWritable(path string, flag int) (*os.File, error) {
+	file, err := os.OpenFile(path, flag|noFollow, 0644)
+	if os.IsNotExist(err) {
+		file, err = os.OpenFile(path, flag|os.O_CREATE|os.O_EXCL|noFollow, 0644)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !isOpenedPath(file, path) {
+		file.Close()
+		return nil, errOutsideRoots
+	}
+
+	return file, nil
+}
+
+func builtinListDir(args ...object.Object) object.Object {
+	path, err := pathArg("list_dir", args, 1, false)
+	if err != nil {
+		return err
+	}
+
+	infos, readErr := ioutil.ReadDir(path)
+	if readErr != nil {
+		return fileError("list_dir", args[0], readErr)
+	}
+
+	names := make([]string, len(infos))
+	for i, info := range infos {
+		names[i] = info.Name()
+	}
+
+	return stringsToArray(names)
+}
+
+func builtinExists(args ...object.Object) object.Object {
+	path, err := pathArg("exists", args, 1, false)
+	if err != nil {
+		return err
+	}
+
+	_, statErr := os.Stat(path)
+	if os.IsNotExist(statErr) {
+		return FALSE
+	}
+	if statErr != nil {
+		return fileError("exists", args[0], statErr)
+	}
+
+	return TRUE
+}
+
+func builtinRemove(args ...object.Object) object.Object {
+	path, err := pathArg("remove", args, 1, true)
+	if err != nil {
+		return err
+	}
+
+	if removeErr := os.Remove(path); removeErr != nil {
+		return fileError("remove", args[0], removeErr)
+	}
+
+	return NIL
+}
+
+// pathArg validates the arguments of a filesystem builtin and returns the
+// path in the first one, once it's known to be inside the allowed roots.
+func pathArg(name string, args []object.Object, want int, writes bool) (string, *object.Error) {
+	if len(args) != want {
+		return "", object.NewError("wrong number of arguments. got=%d, want=%d",
+			len(args), want)
+	}
+	str, ok := args[0].(*object.String)
+	if !ok {
+		return "", object.NewError("first argument to `%s` must be STRING, got %s", name, args[0].Type())
+	}
+
+	if len(filesystem.Roots) == 0 {
+		return "", object.NewError("can't use `%s`, filesystem access is disabled", name)
+	}
+	if writes && filesystem.ReadOnly {
+		return "", object.NewError("can't use `%s`, filesystem access is read-only", name)
+	}
+
+	path, err := resolvePath(str.Value)
+	if err != nil {
+		return "", object.NewError("invalid path %q: %s", str.Value, err)
+	}
+	if !isAllowedPath(path) {
+		return "", object.NewError("access to %q is outside the allowed directories", str.Value)
+	}
+
+	return path, nil
+}
+
+// resolvePath makes the path absolute and follows symlinks, so that they
+// can't be used to escape the allowed roots. The end of the path may not
+// exist yet, for when a file is going to be created, but it can't be a
+// symlink to a missing file, as creating it would create its target.
+func resolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	missing := ""
+	for {
+		resolved, err := filepath.EvalSymlinks(abs)
+		if err == nil {
+			return filepath.Join(resolved, missing), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		if _, err := os.Lstat(abs); err == nil {
+			return "", errDanglingSymlink
+		}
+
+		parent := filepath.Dir(abs)
+		if parent == abs {
+			return "", err
+		}
+		missing = filepath.Join(filepath.Base(abs), missing)
+		abs = parent
+	}
+}
+
+var (
+	errDanglingSymlink = errors.New("symlink to a missing file")
+	errOutsideRoots    = errors.New("outside the allowed directories")
+)
+
+// isOpenedPath tells whether file, just opened from path, is the file at
+// path once its symlinks are followed again, and that's still inside the
+// allowed roots. It catches symlinks put in place after the path was checked.
+func isOpenedPath(file *os.File, path string) bool {
+	opened, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil || !isAllowedPath(resolved) {
+		return false
+	}
+	info, err := os.Stat(resolved)
+
+	return err == nil && os.SameFile(opened, info)
+}
+
+func isAllowedPath(path string) bool {
+	for _, root := range filesystem.Roots {
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			continue
+		}
+		if rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func fileError(name string, path object.Object, err error) *object.Error {
+	if pathErr, ok := err.(*os.PathError); ok {
+		err = pathErr.Err
+	}
+
+	return object.NewError("`%s` failed for %s: %s", name, path.Inspect(), err)
+}
@@ -0,0 +1,273 @@
+package evaluator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/uesteibar/lainoa/pkg/object"
+)
+
+func init() {
+	builtins["json_parse"] = &object.Builtin{Fn: builtinJSONParse}
+	builtins["json_stringify"] = &object.Builtin{Fn: builtinJSONStringify}
+}
+
+// jsonError is a problem found while decoding JSON, at a byte offset of the
+// input.
+type jsonError struct {
+	offset  int64
+	message string
+}
+
+func (e *jsonError) Error() string { return e.message }
+
+// builtinJSONParse turns objects into hashes, arrays into arrays, and
+// strings, booleans and null into their Lainoa counterparts. Lainoa has no
+// floating point numbers, so only integer numbers are supported.
+func builtinJSONParse(args ...object.Object) object.Object {
+	strs, err := stringArgs("json_parse", args, 1)
+	if err != nil {
+		return err
+	}
+	input := strs[0]
+
+	dec := json.NewDecoder(strings.NewReader(input))
+	dec.UseNumber()
+
+	value, decodeErr := decodeJSON(dec)
+	if decodeErr == nil {
+		end := dec.InputOffset()
+		if _, trailingErr := dec.Token(); trailingErr != io.EOF {
+			trailing := strings.TrimLeft(input[end:], " \t\r\n")
+			decodeErr = &jsonError{
+				offset:  int64(len(input) - len(trailing)),
+				message: "unexpected data after the JSON value",
+			}
+		}
+	}
+	if decodeErr != nil {
+		line, column := jsonPosition(input, decodeErr)
+		return object.NewError("invalid JSON at line %d, column %d: %s", line, column, decodeErr.Error())
+	}
+
+	return value
+}
+
+func decodeJSON(dec *json.Decoder) (object.Object, error) {
+	tok, err := dec.Token()
+	if err == io.EOF {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok := tok.(type) {
+	case json.Delim:
+		if tok == '{' {
+			return decodeJSONObject(dec)
+		}
+		return decodeJSONArray(dec)
+	case json.Number:
+		if value, err := strconv.ParseInt(tok.String(), 10, 64); err == nil {
+			return &object.Integer{Value: value}, nil
+		}
+		if value, ok := new(big.Int).SetString(tok.String(), 10); ok {
+			return object.NewBigInteger(value), nil
+		}
+		return nil, &jsonError{
+			offset:  dec.InputOffset() - int64(len(tok.String())),
+			message: fmt.Sprintf("number %s is not supported, only integers are", tok),
+		}
+	case string:
+		return &object.String{Value: tok}, nil
+	case bool:
+		return nativeBoolToBoolean(tok), nil
+	default:
+		return NIL, nil
+	}
+}
+
+func decodeJSONObject(dec *json.Decoder) (object.Object, error) {
+	hash := object.NewHash()
+
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := decodeJSON(dec)
+		if err != nil {
+			return nil, err
+		}
+
+		hash.Set(&object.String{Value: key.(string)}, value)
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+
+	return hash, nil
+}
+
+func decodeJSONArray(dec *json.Decoder) (object.Object, error) {
+	elements := []object.Object{}
+
+	for dec.More() {
+		value, err := decodeJSON(dec)
+		if err != nil {
+			return nil, err
+		}
+
+		elements = append(elements, value)
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+
+	return &object.Array{Elements: elements}, nil
+}
+
+// jsonPosition finds the line and column (counted in characters, starting at
+// 1) where decoding failed.
+func jsonPosition(input string, err error) (int, int) {
+	offset := int64(len(input))
+	switch err := err.(type) {
+	case *json.SyntaxError:
+		// the offset is right after the character that caused the error,
+		// unless the input ended too early
+		if !strings.Contains(err.Error(), "end of JSON input") {
+			offset = err.Offset - 1
+		}
+	case *jsonError:
+		offset = err.offset
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > int64(len(input)) {
+		offset = int64(len(input))
+	}
+
+	before := input[:offset]
+	line := strings.Count(before, "\n") + 1
+	column := utf8.RuneCountInString(before[strings.LastIndex(before, "\n")+1:]) + 1
+
+	return line, column
+}
+
+// maxJSONIndent is the most spaces json_stringify indents with per level.
+const maxJSONIndent = 10
+
+func builtinJSONStringify(args ...object.Object) object.Object {
+	if len(args) != 1 && len(args) != 2 {
+		return object.NewError("wrong number of arguments. got=%d, want=1..2",
+			len(args))
+	}
+	indent := int64(0)
+	if len(args) == 2 {
+		integer, ok := args[1].(*object.Integer)
+		if !ok {
+			return object.NewError("second argument to `json_stringify` must be INTEGER, got %s", args[1].Type())
+		}
+		if integer.Value < 0 {
+			return object.NewError("second argument to `json_stringify` can't be negative, got %s", integer.Inspect())
+		}
+		if integer.Big != nil || integer.Value > maxJSONIndent {
+			return object.NewError("second argument to `json_stringify` can't be more than %d, got %s", maxJSONIndent, integer.Inspect())
+		}
+		indent = integer.Value
+	}
+
+	var out bytes.Buffer
+	if err := encodeJSON(&out, args[0], "$", map[object.Object]bool{}); err != nil {
+		return err
+	}
+
+	if indent > 0 {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, out.Bytes(), "", strings.Repeat(" ", int(indent))); err != nil {
+			return object.NewError("couldn't indent JSON: %s", err)
+		}
+		return &object.String{Value: indented.String()}
+	}
+
+	return &object.String{Value: out.String()}
+}
+
+// encodeJSON writes obj to out as JSON. path tells where obj is within the
+// value being serialized, e.g. $["users"][0], to point at what can't be
+// serialized. seen has the arrays and hashes obj is inside of, as one that
+// contains itself can't be serialized.
+func encodeJSON(out *bytes.Buffer, obj object.Object, path string, seen map[object.Object]bool) *object.Error {
+	switch obj.(type) {
+	case *object.Array, *object.Hash:
+		if seen[obj] {
+			return object.NewError("can't serialize %s at %s to JSON, it contains itself", obj.Type(), path)
+		}
+		seen[obj] = true
+		defer delete(seen, obj)
+	}
+
+	switch obj := obj.(type) {
+	case *object.Nil:
+		out.WriteString("null")
+	case *object.Boolean:
+		out.WriteString(strconv.FormatBool(obj.Value))
+	case *object.Integer:
+		out.WriteString(obj.Inspect())
+	case *object.String:
+		encodeJSONString(out, obj.Value)
+	case *object.Array:
+		out.WriteString("[")
+		for i, el := range obj.Elements {
+			if i > 0 {
+				out.WriteString(",")
+			}
+			if err := encodeJSON(out, el, fmt.Sprintf("%s[%d]", path, i), seen); err != nil {
+				return err
+			}
+		}
+		out.WriteString("]")
+	case *object.Hash:
+		out.WriteString("{")
+		for i, pair := range obj.Pairs() {
+			key, ok := pair.Key.(*object.String)
+			if !ok {
+				return object.NewError("can't serialize %s at %s to JSON, hash keys must be STRING, got %s",
+					pair.Key.Inspect(), path, pair.Key.Type())
+			}
+			if i > 0 {
+				out.WriteString(",")
+			}
+			encodeJSONString(out, key.Value)
+			out.WriteString(":")
+			if err := encodeJSON(out, pair.Value, fmt.Sprintf("%s[%q]", path, key.Value), seen); err != nil {
+				return err
+			}
+		}
+		out.WriteString("}")
+	default:
+		return object.NewError("can't serialize %s at %s to JSON", obj.Type(), path)
+	}
+
+	return nil
+}
+
+func encodeJSONString(out *bytes.Buffer, str string) {
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+	enc.Encode(str)
+
+	// Encode always ends values with a new line
+	out.Truncate(out.Len() - 1)
+}
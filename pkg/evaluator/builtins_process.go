@@ -0,0 +1,63 @@
+package evaluator
+
+import (
+	"os"
+
+	"github.com/uesteibar/lainoa/pkg/object"
+)
+
+var scriptArgs []string
+
+// SetArgs sets the command line arguments returned by the `args` builtin.
+func SetArgs(args []string) {
+	scriptArgs = args
+}
+
+func init() {
+	builtins["args"] = &object.Builtin{Fn: builtinArgs}
+	builtins["env"] = &object.Builtin{Fn: builtinEnv}
+	builtins["exit"] = &object.Builtin{Fn: builtinExit}
+}
+
+func builtinArgs(args ...object.Object) object.Object {
+	if len(args) != 0 {
+		return object.NewError("wrong number of arguments. got=%d, want=0",
+			len(args))
+	}
+
+	return stringsToArray(scriptArgs)
+}
+
+func builtinEnv(args ...object.Object) object.Object {
+	strs, err := stringArgs("env", args, 1)
+	if err != nil {
+		return err
+	}
+
+	value, ok := os.LookupEnv(strs[0])
+	if !ok {
+		return NIL
+	}
+
+	return &object.String{Value: value}
+}
+
+func builtinExit(args ...object.Object) object.Object {
+	if len(args) > 1 {
+		return object.NewError("wrong number of arguments. got=%d, want=0..1",
+			len(args))
+	}
+	if len(args) == 0 {
+		return object.NewExit(0)
+	}
+
+	code, ok := args[0].(*object.Integer)
+	if !ok {
+		return object.NewError("argument to `exit` must be INTEGER, got %s", args[0].Type())
+	}
+	if code.Value < 0 || code.Value > 255 {
+		return object.NewError("exit status must be between 0 and 255, got %s", code.Inspect())
+	}
+
+	return object.NewExit(int(code.Value))
+}
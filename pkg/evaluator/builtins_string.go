@@ -0,0 +1,304 @@
+package evaluator
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/uesteibar/lainoa/pkg/object"
+)
+
+// maxStringLength is the longest string, in bytes, repeat and padding can
+// build, so a typo in a count fails instead of exhausting the memory.
+const maxStringLength = 1 << 30
+
+func init() {
+	builtins["split"] = &object.Builtin{Fn: builtinSplit}
+	builtins["join"] = &object.Builtin{Fn: builtinJoin}
+	builtins["trim"] = &object.Builtin{Fn: builtinTrim}
+	builtins["upper"] = &object.Builtin{Fn: builtinUpper}
+	builtins["lower"] = &object.Builtin{Fn: builtinLower}
+	builtins["contains"] = &object.Builtin{Fn: builtinContains}
+	builtins["starts_with"] = &object.Builtin{Fn: builtinStartsWith}
+	builtins["ends_with"] = &object.Builtin{Fn: builtinEndsWith}
+	builtins["replace"] = &object.Builtin{Fn: builtinReplace}
+	builtins["index_of"] = &object.Builtin{Fn: builtinIndexOf}
+	builtins["repeat"] = &object.Builtin{Fn: builtinRepeat}
+	builtins["chars"] = &object.Builtin{Fn: builtinChars}
+	builtins["pad_left"] = &object.Builtin{Fn: builtinPadLeft}
+	builtins["pad_right"] = &object.Builtin{Fn: builtinPadRight}
+	builtins["bytes"] = &object.Builtin{Fn: builtinBytes}
+}
+
+func builtinSplit(args ...object.Object) object.Object {
+	strs, err := stringArgs("split", args, 2)
+	if err != nil {
+		return err
+	}
+
+	if strs[1] == "" {
+		return stringsToArray(splitChars(strs[0]))
+	}
+
+	return stringsToArray(strings.Split(strs[0], strs[1]))
+}
+
+func builtinJoin(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return object.NewError("wrong number of arguments. got=%d, want=2",
+			len(args))
+	}
+	arr, ok := args[0].(*object.Array)
+	if !ok {
+		return object.NewError("first argument to `join` must be ARRAY, got %s", args[0].Type())
+	}
+	sep, ok := args[1].(*object.String)
+	if !ok {
+		return object.NewError("second argument to `join` must be STRING, got %s", args[1].Type())
+	}
+
+	strs := make([]string, len(arr.Elements))
+	for i, el := range arr.Elements {
+		str, ok := el.(*object.String)
+		if !ok {
+			return object.NewError("elements to `join` must be STRING, got %s", el.Type())
+		}
+		strs[i] = str.Value
+	}
+
+	return &object.String{Value: strings.Join(strs, sep.Value)}
+}
+
+func builtinTrim(args ...object.Object) object.Object {
+	strs, err := stringArgs("trim", args, 1)
+	if err != nil {
+		return err
+	}
+
+	return &object.String{Value: strings.TrimSpace(strs[0])}
+}
+
+func builtinUpper(args ...object.Object) object.Object {
+	strs, err := stringArgs("upper", args, 1)
+	if err != nil {
+		return err
+	}
+
+	return &object.String{Value: strings.ToUpper(strs[0])}
+}
+
+func builtinLower(args ...object.Object) object.Object {
+	strs, err := stringArgs("lower", args, 1)
+	if err != nil {
+		return err
+	}
+
+	return &object.String{Value: strings.ToLower(strs[0])}
+}
+
+func builtinContains(args ...object.Object) object.Object {
+	strs, err := stringArgs("contains", args, 2)
+	if err != nil {
+		return err
+	}
+
+	return nativeBoolToBoolean(strings.Contains(strs[0], strs[1]))
+}
+
+func builtinStartsWith(args ...object.Object) object.Object {
+	strs, err := stringArgs("starts_with", args, 2)
+	if err != nil {
+		return err
+	}
+
+	return nativeBoolToBoolean(strings.HasPrefix(strs[0], strs[1]))
+}
+
+func builtinEndsWith(args ...object.Object) object.Object {
+	strs, err := stringArgs("ends_with", args, 2)
+	if err != nil {
+		return err
+	}
+
+	return nativeBoolToBoolean(strings.HasSuffix(strs[0], strs[1]))
+}
+
+func builtinReplace(args ...object.Object) object.Object {
+	strs, err := stringArgs("replace", args, 3)
+	if err != nil {
+		return err
+	}
+
+	return &object.String{Value: strings.Replace(strs[0], strs[1], strs[2], -1)}
+}
+
+// builtinIndexOf returns the position of the first occurrence counted in
+// characters rather than bytes, or -1 when there's none.
+func builtinIndexOf(args ...object.Object) object.Object {
+	strs, err := stringArgs("index_of", args, 2)
+	if err != nil {
+		return err
+	}
+
+	idx := strings.Index(strs[0], strs[1])
+	if idx == -1 {
+		return &object.Integer{Value: -1}
+	}
+
+	return &object.Integer{Value: int64(utf8.RuneCountInString(strs[0][:idx]))}
+}
+
+func builtinRepeat(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return object.NewError("wrong number of arguments. got=%d, want=2",
+			len(args))
+	}
+	str, ok := args[0].(*object.String)
+	if !ok {
+		return object.NewError("first argument to `repeat` must be STRING, got %s", args[0].Type())
+	}
+	count, ok := args[1].(*object.Integer)
+	if !ok {
+		return object.NewError("second argument to `repeat` must be INTEGER, got %s", args[1].Type())
+	}
+	if count.Value < 0 {
+		return object.NewError("second argument to `repeat` can't be negative, got %s", count.Inspect())
+	}
+	if count.Big != nil {
+		return object.NewError("second argument to `repeat` is too big, got %s", count.Inspect())
+	}
+	if count.Value > 0 && int64(len(str.Value)) > maxStringLength/count.Value {
+		return object.NewError("result of `repeat` can't be longer than %d bytes", maxStringLength)
+	}
+
+	return &object.String{Value: strings.Repeat(str.Value, int(count.Value))}
+}
+
+func builtinChars(args ...object.Object) object.Object {
+	strs, err := stringArgs("chars", args, 1)
+	if err != nil {
+		return err
+	}
+
+	return stringsToArray(splitChars(strs[0]))
+}
+
+// builtinBytes exposes the UTF-8 encoding of a string, for when characters
+// aren't what's needed.
+func builtinBytes(args ...object.Object) object.Object {
+	strs, err := stringArgs("bytes", args, 1)
+	if err != nil {
+		return err
+	}
+
+	elements := make([]object.Object, len(strs[0]))
+	for i := 0; i < len(strs[0]); i++ {
+		elements[i] = &object.Integer{Value: int64(strs[0][i])}
+	}
+
+	return &object.Array{Elements: elements}
+}
+
+func builtinPadLeft(args ...object.Object) object.Object {
+	str, padding, err := paddingArgs("pad_left", args)
+	if err != nil {
+		return err
+	}
+
+	return &object.String{Value: padding + str}
+}
+
+func builtinPadRight(args ...object.Object) object.Object {
+	str, padding, err := paddingArgs("pad_right", args)
+	if err != nil {
+		return err
+	}
+
+	return &object.String{Value: str + padding}
+}
+
+// paddingArgs validates the arguments to pad_left and pad_right, which are
+// the string, the width to pad it to, and optionally what to pad it with (a
+// space by default). It returns the string and the padding it needs, so it's
+// as wide as requested, in characters.
+func paddingArgs(name string, args []object.Object) (string, string, *object.Error) {
+	if len(args) != 2 && len(args) != 3 {
+		return "", "", object.NewError("wrong number of arguments. got=%d, want=2..3",
+			len(args))
+	}
+	str, ok := args[0].(*object.String)
+	if !ok {
+		return "", "", object.NewError("first argument to `%s` must be STRING, got %s", name, args[0].Type())
+	}
+	width, ok := args[1].(*object.Integer)
+	if !ok {
+		return "", "", object.NewError("second argument to `%s` must be INTEGER, got %s", name, args[1].Type())
+	}
+	if width.Big != nil {
+		return "", "", object.NewError("second argument to `%s` is too big, got %s", name, width.Inspect())
+	}
+	pad := " "
+	if len(args) == 3 {
+		padStr, ok := args[2].(*object.String)
+		if !ok {
+			return "", "", object.NewError("third argument to `%s` must be STRING, got %s", name, args[2].Type())
+		}
+		if padStr.Value == "" {
+			return "", "", object.NewError("third argument to `%s` can't be empty", name)
+		}
+		pad = padStr.Value
+	}
+	if width.Value > maxStringLength/int64(len(pad)) {
+		return "", "", object.NewError("result of `%s` can't be longer than %d bytes", name, maxStringLength)
+	}
+
+	missing := int(width.Value) - utf8.RuneCountInString(str.Value)
+	if missing <= 0 {
+		return str.Value, "", nil
+	}
+
+	padRunes := []rune(strings.Repeat(pad, missing/utf8.RuneCountInString(pad)+1))
+	return str.Value, string(padRunes[:missing]), nil
+}
+
+// stringArgs checks there are exactly as many arguments as wanted, and that
+// all of them are strings.
+func stringArgs(name string, args []object.Object, want int) ([]string, *object.Error) {
+	if len(args) != want {
+		return nil, object.NewError("wrong number of arguments. got=%d, want=%d",
+			len(args), want)
+	}
+
+	strs := make([]string, len(args))
+	for i, arg := range args {
+		str, ok := arg.(*object.String)
+		if !ok {
+			if want == 1 {
+				return nil, object.NewError("argument to `%s` must be STRING, got %s", name, arg.Type())
+			}
+			return nil, object.NewError("%s argument to `%s` must be STRING, got %s", ordinals[i], name, arg.Type())
+		}
+		strs[i] = str.Value
+	}
+
+	return strs, nil
+}
+
+var ordinals = []string{"first", "second", "third"}
+
+func splitChars(str string) []string {
+	chars := []string{}
+	for _, r := range str {
+		chars = append(chars, string(r))
+	}
+
+	return chars
+}
+
+func stringsToArray(strs []string) *object.Array {
+	elements := make([]object.Object, len(strs))
+	for i, str := range strs {
+		elements[i] = &object.String{Value: str}
+	}
+
+	return &object.Array{Elements: elements}
+}
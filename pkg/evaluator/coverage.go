@@ -0,0 +1,13 @@
+package evaluator
+
+import (
+	"github.com/uesteibar/lainoa/pkg/coverage"
+)
+
+var cover *coverage.Profile
+
+// SetCoverage makes the evaluator record every statement and `if` branch it
+// evaluates in profile. Passing nil turns coverage off.
+func SetCoverage(profile *coverage.Profile) {
+	cover = profile
+}
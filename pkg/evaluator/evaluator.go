@@ -14,6 +14,9 @@ var (
 func Eval(node ast.Node, env *object.Environment) object.Object {
 	switch node := node.(type) {
 	case *ast.Program:
+		if cover != nil {
+			cover.AddProgram(node)
+		}
 		return evalProgram(node.Statements, env)
 	case *ast.BlockStatement:
 		return evalBlockStatement(node.Statements, env)
@@ -23,6 +26,8 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return evalLetStatement(node, env)
 	case *ast.ExpressionStatement:
 		return Eval(node.Expression, env)
+	case *ast.FunctionDeclaration:
+		return evalFunctionDeclaration(node, env)
 	case *ast.FunctionLiteral:
 		return evalFunctionLiteral(node, env)
 	case *ast.CallExpression:
@@ -31,16 +36,26 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return evalPrefix(node, env)
 	case *ast.InfixExpression:
 		return evalInfix(node, env)
+	case *ast.PipeExpression:
+		return evalPipe(node, env)
 	case *ast.IfExpression:
 		return evalIfExpression(node, env)
 	case *ast.AssignExpression:
 		return evalAssign(node, env)
+	case *ast.IndexAssignExpression:
+		return evalIndexAssign(node, env)
 	case *ast.ArrayExpression:
 		return evalArray(node, env)
+	case *ast.HashLiteral:
+		return evalHashLiteral(node, env)
 	case *ast.IndexExpression:
 		return evalIndexOperation(node, env)
+	case *ast.SliceExpression:
+		return evalSliceOperation(node, env)
+	case *ast.SpreadExpression:
+		return object.NewError("spread `%s` is only allowed in call arguments and arrays", node.String())
 	case *ast.IntegerLiteral:
-		return &object.Integer{Value: node.Value}
+		return &object.Integer{Value: node.Value, Big: node.Big}
 	case *ast.StringLiteral:
 		return &object.String{Value: node.Value}
 	case *ast.NilLiteral:
@@ -57,7 +72,19 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 func evalProgram(statements []ast.Statement, env *object.Environment) object.Object {
 	var res object.Object
 
+	if err := hoistFunctionDeclarations(statements, env); err != nil {
+		return err
+	}
+
 	for _, stmt := range statements {
+		if cover != nil {
+			cover.RecordStatement(stmt)
+		}
+		if tracer != nil {
+			if err := tracer.Statement(stmt, env); err != nil {
+				return err
+			}
+		}
 		res = Eval(stmt, env)
 
 		if returnValue, ok := res.(*object.ReturnValue); ok {
@@ -74,7 +101,19 @@ func evalProgram(statements []ast.Statement, env *object.Environment) object.Obj
 func evalBlockStatement(statements []ast.Statement, env *object.Environment) object.Object {
 	var res object.Object
 
+	if err := hoistFunctionDeclarations(statements, env); err != nil {
+		return err
+	}
+
 	for _, stmt := range statements {
+		if cover != nil {
+			cover.RecordStatement(stmt)
+		}
+		if tracer != nil {
+			if err := tracer.Statement(stmt, env); err != nil {
+				return err
+			}
+		}
 		res = Eval(stmt, env)
 
 		if res != nil && res.Type() == object.RETURN_VALUE_OBJECT {
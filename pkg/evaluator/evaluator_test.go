@@ -1,12 +1,18 @@
 package evaluator
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/uesteibar/lainoa/pkg/lexer"
 	"github.com/uesteibar/lainoa/pkg/object"
 	"github.com/uesteibar/lainoa/pkg/parser"
+	"github.com/uesteibar/lainoa/pkg/profiler"
 )
 
 func eval(input string) object.Object {
@@ -69,6 +75,70 @@ func TestEvalIntegerExpression(t *testing.T) {
 	}
 }
 
+func TestEvalBigIntegers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		big      bool
+	}{
+		{"9223372036854775807 + 1", "9223372036854775808", true},
+		{"-9223372036854775807 - 2", "-9223372036854775809", true},
+		{"9223372036854775807 * 9223372036854775807", "85070591730234615847396907784232501249", true},
+		{"-9223372036854775807 - 1", "-9223372036854775808", false},
+		{"-(-9223372036854775807 - 1)", "9223372036854775808", true},
+		{"(-9223372036854775807 - 1) / -1", "9223372036854775808", true},
+		{"(-9223372036854775807 - 1) * -1", "9223372036854775808", true},
+		{"123456789012345678901234567890", "123456789012345678901234567890", true},
+		{"-123456789012345678901234567890", "-123456789012345678901234567890", true},
+		{"+123456789012345678901234567890", "123456789012345678901234567890", true},
+		{"123456789012345678901234567890 - 123456789012345678901234567889", "1", false},
+		{"123456789012345678901234567890 / 10", "12345678901234567890123456789", true},
+		{"(9223372036854775807 + 1) / 2", "4611686018427387904", false},
+		{"9223372036854775808 == 9223372036854775807 + 1", "true", false},
+		{"9223372036854775808 != 9223372036854775807", "true", false},
+		{"9223372036854775808 > 9223372036854775807", "true", false},
+		{"-9223372036854775809 < -9223372036854775808", "true", false},
+		{"1 < 99999999999999999999", "true", false},
+		{`to_string(99999999999999999999)`, `"99999999999999999999"`, false},
+		{`{99999999999999999999: "big", 1: "small"}[99999999999999999998 + 1]`, `"big"`, false},
+		{`sort([99999999999999999999, 1, -99999999999999999999])`, "[-99999999999999999999, 1, 99999999999999999999]", false},
+		{`json_stringify([99999999999999999999])`, `"[99999999999999999999]"`, false},
+	}
+
+	for _, tt := range tests {
+		evaluated := eval(tt.input)
+		assert.Equal(t, tt.expected, evaluated.Inspect(), tt.input)
+
+		if integer, ok := evaluated.(*object.Integer); ok {
+			assert.Equal(t, tt.big, integer.Big != nil, tt.input)
+		}
+	}
+}
+
+func TestBigIntegerErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[1, 2][99999999999999999999]", "nil"},
+		{"let a = [1, 2]; a[99999999999999999999] = 3", "ERROR: index 99999999999999999999 out of range for array of length 2"},
+		{"exit(99999999999999999999)", "ERROR: exit status must be between 0 and 255, got 99999999999999999999"},
+		{`repeat("a", -99999999999999999999)`, "ERROR: second argument to `repeat` can't be negative, got -99999999999999999999"},
+		{`repeat("", 99999999999999999999)`, "ERROR: second argument to `repeat` is too big, got 99999999999999999999"},
+		{`pad_left("a", -99999999999999999999)`, "ERROR: second argument to `pad_left` is too big, got -99999999999999999999"},
+		{"range(99999999999999999999)", "ERROR: arguments to `range` are too big, got 99999999999999999999"},
+		{"range(-99999999999999999999, 0)", "ERROR: arguments to `range` are too big, got -99999999999999999999"},
+		{"json_stringify(1, 99999999999999999999)", "ERROR: second argument to `json_stringify` can't be more than 10, got 99999999999999999999"},
+		{"1 / 0", "ERROR: division by zero"},
+		{"99999999999999999999 / 0", "ERROR: division by zero"},
+		{"let a = 1; a /= 0", "ERROR: division by zero"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, eval(tt.input).Inspect(), tt.input)
+	}
+}
+
 func TestEvalStringExpression(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -261,6 +331,30 @@ func TestErrorHandling(t *testing.T) {
 			"let a = 1; let a = 10;",
 			"can't re-bind already bound identifier `a`",
 		},
+		{
+			"a += 1;",
+			"identifier not found: a",
+		},
+		{
+			`let a = 1; a += "1";`,
+			"type mismatch: INTEGER + STRING",
+		},
+		{
+			"let a = [1, 2]; a[2] = 3;",
+			"index 2 out of range for array of length 2",
+		},
+		{
+			`let a = [1, 2]; a["0"] = 3;`,
+			"expected INTEGER as index for array, got STRING",
+		},
+		{
+			`let a = "lainoa"; a[0] = "L";`,
+			"type STRING doesn't support index assignment",
+		},
+		{
+			`let a = [true]; a[0] += 1;`,
+			"type mismatch: BOOLEAN + INTEGER",
+		},
 	}
 
 	for _, tt := range tests {
@@ -285,6 +379,12 @@ func TestLetStatements(t *testing.T) {
 		{"let a = 5; if (a == 5) { a + 10 };", 15},
 		{"let a = 5; a = 10; a;", 10},
 		{"let a = 5; if (a == 5) { a = 10 }; a;", 10},
+		{"let a = 5; a += 10; a;", 15},
+		{"let a = 5; a -= 10; a;", -5},
+		{"let a = 5; a *= 10; a;", 50},
+		{"let a = 50; a /= 10; a;", 5},
+		{"let a = 5; a += 1;", 6},
+		{"let a = 5; if (true) { a += 1 }; a;", 6},
 	}
 
 	for _, tt := range tests {
@@ -304,6 +404,64 @@ func TestFunctionObject(t *testing.T) {
 	assert.Equal(t, "(x + 2)", fn.Body.String())
 }
 
+func TestFunctionDeclarations(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fun add(a, b) { a + b }; add(1, 2)", "3"},
+		{"add(1, 2); fun add(a, b) { a + b }", "fn add(a, b) {\n(a + b)\n}"},
+		{"let res = add(1, 2); fun add(a, b) { a + b }; res", "3"},
+		{
+			`
+			fun is_even(n) { if (n == 0) { true } else { is_odd(n - 1) } }
+			fun is_odd(n) { if (n == 0) { false } else { is_even(n - 1) } }
+
+			[is_even(10), is_odd(7), is_even(3)]
+			`,
+			"[true, true, false]",
+		},
+		{
+			`
+			let outer = fun(n) {
+				let res = double(n)
+				fun double(x) { x * 2 }
+				res
+			}
+
+			outer(4)
+			`,
+			"8",
+		},
+		{"fun add(a, b) { a + b }; let add_one = add(1); add_one(2)", "3"},
+	}
+
+	for _, tt := range tests {
+		evaluated := eval(tt.input)
+		assert.Equal(t, tt.expected, evaluated.Inspect())
+	}
+}
+
+func TestFunctionDeclarationErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fun add(a) { a }; add(1, 2)", "`add` expected 1 arguments, got 2"},
+		{"let add = 1; fun add(a) { a }", "can't re-bind already bound identifier `add`"},
+		{"fun add(a) { a }; fun add(b) { b }", "can't re-bind already bound identifier `add`"},
+		{"if (true) { double(1) }; fun double(x) { x * 2 }; scoped(); fun outer() { fun scoped() { 1 } }", "identifier not found: scoped"},
+	}
+
+	for _, tt := range tests {
+		evaluated := eval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		assert.True(t, ok)
+		assert.Equal(t, tt.expected, errObj.Message)
+	}
+}
+
 func TestFunctionCalls(t *testing.T) {
 	evaluated := eval(`
 		let multiply = fun(num) {
@@ -357,6 +515,149 @@ func TestFunctionCurrying(t *testing.T) {
 	}
 }
 
+func TestFunctionDefaultParameters(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let f = fun(a, b = 2) { a * b }; f(5)", "10"},
+		{"let f = fun(a, b = 2) { a * b }; f(5, 3)", "15"},
+		{"let f = fun(a = 1, b = 2) { a + b }; f()", "3"},
+		{"let f = fun(a, b = a * 2) { b }; f(5)", "10"},
+		{"let f = fun(a, b, c = 1) { a + b + c }; f(1)(2)", "4"},
+		{"let f = fun(a, b, c = 1) { a + b + c }; f(1)(2, 3)", "6"},
+		{"let f = fun(a, b = 2) { a * b }; let g = f(); g(4)", "8"},
+	}
+
+	for _, tt := range tests {
+		evaluated := eval(tt.input)
+		assert.Equal(t, tt.expected, evaluated.Inspect())
+	}
+}
+
+func TestFunctionRestParameters(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let f = fun(...others) { others }; f()", "[]"},
+		{"let f = fun(...others) { others }; f(1, 2, 3)", "[1, 2, 3]"},
+		{"let f = fun(a, ...others) { [a, others] }; f(1, 2, 3)", "[1, [2, 3]]"},
+		{"let f = fun(a, b = 2, ...others) { [a, b, others] }; f(1)", "[1, 2, []]"},
+		{"let f = fun(a, b = 2, ...others) { [a, b, others] }; f(1, 3, 4)", "[1, 3, [4]]"},
+		{"let f = fun(a, b, ...others) { [a, b, others] }; f(1)(2, 3, 4)", "[1, 2, [3, 4]]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := eval(tt.input)
+		assert.Equal(t, tt.expected, evaluated.Inspect())
+	}
+}
+
+func TestSpreadArguments(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let f = fun(a, b, c) { a + b + c }; f(...[1, 2, 3])", "6"},
+		{"let f = fun(a, b, c) { a + b + c }; f(1, ...[2, 3])", "6"},
+		{"let f = fun(a, b, c) { a + b + c }; f(...[1])(2, 3)", "6"},
+		{"let f = fun(...others) { others }; f(...[1, 2], 3, ...[4])", "[1, 2, 3, 4]"},
+		{"let list = [2, 3]; [1, ...list, 4]", "[1, 2, 3, 4]"},
+		{"push(...[[1], 2])", "[1, 2]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := eval(tt.input)
+		assert.Equal(t, tt.expected, evaluated.Inspect())
+	}
+}
+
+func TestSpreadErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let f = fun(a) { a }; f(...1)", "can't spread INTEGER, expected ARRAY"},
+		{"let f = fun(a) { a }; f(...[1, 2])", "`f` expected 1 arguments, got 2"},
+		{"fun(a) { a }(...[1, 2])", "expected 1 arguments, got 2"},
+		{"...[1, 2]", "spread `...[1, 2]` is only allowed in call arguments and arrays"},
+	}
+
+	for _, tt := range tests {
+		evaluated := eval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		assert.True(t, ok)
+		assert.Equal(t, tt.expected, errObj.Message)
+	}
+}
+
+func TestPipe(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let double = fun(x) { x * 2 }; 2 |> double", "4"},
+		{"let double = fun(x) { x * 2 }; 2 + 1 |> double |> double", "12"},
+		{"let sub = fun(a, b) { a - b }; 10 |> sub(3)", "7"},
+		{"[1] |> push(2) |> push(3)", "[1, 2, 3]"},
+		{`"lainoa" |> len`, "6"},
+		{"let sub = fun(a, b) { a - b }; 10 |> sub", "Curried Function: fn sub(a, b) {\n(a - b)\n}"},
+		{"5 |> fun(x) { x + 1 }", "6"},
+		{"let add = fun(a, b, c) { a + b + c }; 1 |> add(...[2, 3])", "6"},
+	}
+
+	for _, tt := range tests {
+		evaluated := eval(tt.input)
+		assert.Equal(t, tt.expected, evaluated.Inspect())
+	}
+}
+
+func TestComposition(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let inc = fun(x) { x + 1 }; let double = fun(x) { x * 2 }; (inc >> double)(3)", "8"},
+		{"let inc = fun(x) { x + 1 }; let double = fun(x) { x * 2 }; (double >> inc)(3)", "7"},
+		{"let inc = fun(x) { x + 1 }; let double = fun(x) { x * 2 }; 3 |> inc >> double >> inc", "9"},
+		{"let add = fun(a, b) { a + b }; let double = fun(x) { x * 2 }; (add >> double)(1, 2)", "6"},
+		{"let add = fun(a, b) { a + b }; let double = fun(x) { x * 2 }; (add(1) >> double)(2)", "6"},
+		{"let first = fun(x) { x[0] }; (push >> first)([1], 2)", "1"},
+		{"let double = fun(x) { x * 2 }; [1, 2] |> push(3) |> len >> double", "6"},
+		{"let sub = fun(a, b) { a - b }; 10 |> sub(3)", "7"},
+		{"let sub = fun(a, b) { a - b }; let id = fun(x) { x }; 10 |> sub(3) >> id", "7"},
+		{"let sub = fun(a, b) { a - b }; let id = fun(x) { x }; 10 |> sub(3) >> id >> sub(1)", "-6"},
+		{"let sub = fun(a, b) { a - b }; 10 |> sub(3) >> 1", "ERROR: can't compose INTEGER, expected a function"},
+	}
+
+	for _, tt := range tests {
+		evaluated := eval(tt.input)
+		assert.Equal(t, tt.expected, evaluated.Inspect())
+	}
+}
+
+func TestPipeAndCompositionErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1 |> 2", "expected 2 to be a function, got INTEGER"},
+		{"1 |> foo(2)", "identifier not found: foo"},
+		{"let f = fun(x) { x }; f >> 1", "can't compose INTEGER, expected a function"},
+		{"let f = fun(x) { x }; (f >> len)(1)", "argument to `len` not supported, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := eval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		assert.True(t, ok)
+		assert.Equal(t, tt.expected, errObj.Message)
+	}
+}
+
 func TestFunctionCallErrors(t *testing.T) {
 	evaluated := eval(`
 		let multiply = fun(num) {
@@ -388,7 +689,7 @@ func TestFunctionTooManyArgumentErrors(t *testing.T) {
 	errObj, ok := evaluated.(*object.Error)
 	assert.True(t, ok)
 
-	assert.Equal(t, "expected 1 arguments, got 2", errObj.Message)
+	assert.Equal(t, "`multiply` expected 1 arguments, got 2", errObj.Message)
 }
 
 func TestFunctionArgumentErrors(t *testing.T) {
@@ -443,6 +744,8 @@ func TestLenBuiltin(t *testing.T) {
 		{`len("")`, 0},
 		{`len("four")`, 4},
 		{`len("hello world")`, 11},
+		{`len("ñandú")`, 5},
+		{`len("€")`, 1},
 		{`len(1)`, "argument to `len` not supported, got INTEGER"},
 		{`len("one", "two")`, "wrong number of arguments. got=2, want=1"},
 	}
@@ -526,6 +829,14 @@ func TestArrayIndex(t *testing.T) {
 		},
 		{
 			"[1, 2, 3][-1]",
+			3,
+		},
+		{
+			"[1, 2, 3][-3]",
+			1,
+		},
+		{
+			"[1, 2, 3][-4]",
 			nil,
 		},
 	}
@@ -554,6 +865,18 @@ func TestIndexError(t *testing.T) {
 			"1000[3]",
 			"type INTEGER doesn't support index operations",
 		},
+		{
+			"\"lainoa\"[true]",
+			"expected INTEGER as index for string, got BOOLEAN",
+		},
+		{
+			"[1, 2, 3][\"1\":]",
+			"expected INTEGER as slice bound, got STRING",
+		},
+		{
+			"1000[1:2]",
+			"type INTEGER doesn't support slice operations",
+		},
 	}
 
 	for _, tt := range tests {
@@ -564,6 +887,109 @@ func TestIndexError(t *testing.T) {
 		assert.Equal(t, tt.expected, err.Message)
 	}
 }
+
+func TestStringIndex(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`"lainoa"[0]`, "l"},
+		{`"lainoa"[5]`, "a"},
+		{`"lainoa"[-2]`, "o"},
+		{`"lainoa"[6]`, nil},
+		{`"lainoa"[-7]`, nil},
+		{`""[0]`, nil},
+	}
+
+	for _, tt := range tests {
+		evaluated := eval(tt.input)
+		str, ok := tt.expected.(string)
+		if ok {
+			assertStringObject(t, evaluated, str)
+		} else {
+			assert.Equal(t, NIL, evaluated)
+		}
+	}
+}
+
+func TestSlice(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[1, 2, 3, 4][1:3]", "[2, 3]"},
+		{"[1, 2, 3, 4][:2]", "[1, 2]"},
+		{"[1, 2, 3, 4][2:]", "[3, 4]"},
+		{"[1, 2, 3, 4][:]", "[1, 2, 3, 4]"},
+		{"[1, 2, 3, 4][-2:]", "[3, 4]"},
+		{"[1, 2, 3, 4][:-1]", "[1, 2, 3]"},
+		{"[1, 2, 3, 4][1:100]", "[2, 3, 4]"},
+		{"[1, 2, 3, 4][-100:1]", "[1]"},
+		{"[1, 2, 3, 4][3:1]", "[]"},
+		{"[1, 2, 3, 4][10:]", "[]"},
+		{"[][0:1]", "[]"},
+		{"let i = 1; [1, 2, 3, 4][i:i + 2]", "[2, 3]"},
+		{`"lainoa"[1:3]`, `"ai"`},
+		{`"lainoa"[2:]`, `"inoa"`},
+		{`"lainoa"[:-2]`, `"lain"`},
+		{`"lainoa"[4:2]`, `""`},
+	}
+
+	for _, tt := range tests {
+		evaluated := eval(tt.input)
+		assert.Equal(t, tt.expected, evaluated.Inspect())
+	}
+}
+
+func TestSliceCopiesArray(t *testing.T) {
+	evaluated := eval(`
+	let array = [1, 2, 3]
+	let copied = array[:]
+	let res = [array == copied, copied]
+	res`)
+
+	res, ok := evaluated.(*object.Array)
+	assert.True(t, ok)
+
+	assertBooleanObject(t, res.Elements[0], false)
+	assert.Equal(t, "[1, 2, 3]", res.Elements[1].Inspect())
+}
+
+func TestArrayIndexAssign(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let a = [1, 2, 3]; a[0] = 10; a", "[10, 2, 3]"},
+		{"let a = [1, 2, 3]; a[-1] = 10; a", "[1, 2, 10]"},
+		{"let a = [1, 2, 3]; a[1] += 10; a", "[1, 12, 3]"},
+		{"let a = [1, 2, 3]; a[1] *= 10; a", "[1, 20, 3]"},
+		{`let a = ["a"]; a[0] += "b"; a`, `["ab"]`},
+		{"let a = [[1, 2], [3, 4]]; a[1][0] = 10; a", "[[1, 2], [10, 4]]"},
+		{"let a = [1, 2, 3]; a[0] = 10", "10"},
+		{"let a = [1, 2, 3]; let i = 0; a[i + 1] = 10; a", "[1, 10, 3]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := eval(tt.input)
+		assert.Equal(t, tt.expected, evaluated.Inspect())
+	}
+}
+
+func TestArrayIndexAssignIsShared(t *testing.T) {
+	evaluated := eval(`
+	let a = [1, 2, 3]
+	let b = a
+	let c = a[:]
+	let update = fun(arr) { arr[0] = 10 }
+
+	update(a)
+	let res = [a, b, c]
+	res`)
+
+	assert.Equal(t, "[[10, 2, 3], [10, 2, 3], [1, 2, 3]]", evaluated.Inspect())
+}
+
 func TestArrayPush(t *testing.T) {
 	evaluated := eval(`
 	let array = [3, 2, 1]
@@ -595,3 +1021,602 @@ func TestArrayLen(t *testing.T) {
 
 	assertIntegerObject(t, evaluated, 3)
 }
+
+func TestHigherOrderBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"map([1, 2, 3], fun(x) { x * 2 })", "[2, 4, 6]"},
+		{"map([], fun(x) { x * 2 })", "[]"},
+		{"let add = fun(a, b) { a + b }; map([1, 2, 3], add(10))", "[11, 12, 13]"},
+		{`map(["a", "bb"], len)`, "[1, 2]"},
+		{"[1, 2, 3] |> map(fun(x) { x + 1 })", "[2, 3, 4]"},
+		{"filter([1, 2, 3, 4], fun(x) { x > 2 })", "[3, 4]"},
+		{"filter([1, nil, false, 0], fun(x) { x })", "[1, 0]"},
+		{"reduce([1, 2, 3], 0, fun(acc, x) { acc + x })", "6"},
+		{"reduce([], 10, fun(acc, x) { acc + x })", "10"},
+		{"reduce([[1], [2, 3]], [], fun(acc, x) { [...acc, ...x] })", "[1, 2, 3]"},
+		{"let sum = 0; each([1, 2, 3], fun(x) { sum += x }); sum", "6"},
+		{"each([1, 2], fun(x) { x })", "[1, 2]"},
+		{"find([1, 2, 3, 4], fun(x) { x > 2 })", "3"},
+		{"find([1, 2], fun(x) { x > 2 })", "nil"},
+		{"any([1, 2, 3], fun(x) { x > 2 })", "true"},
+		{"any([], fun(x) { true })", "false"},
+		{"all([1, 2, 3], fun(x) { x > 0 })", "true"},
+		{"all([1, 2, 3], fun(x) { x > 1 })", "false"},
+		{"all([], fun(x) { false })", "true"},
+		{"sort([3, 1, 2])", "[1, 2, 3]"},
+		{`sort(["b", "c", "a"])`, `["a", "b", "c"]`},
+		{"let list = [3, 1, 2]; sort(list); list", "[3, 1, 2]"},
+		{`sort_by(["ccc", "a", "bb"], len)`, `["a", "bb", "ccc"]`},
+		{`sort_by([[2, "a"], [1, "b"], [2, "c"]], fun(x) { x[0] })`, `[[1, "b"], [2, "a"], [2, "c"]]`},
+		{"zip([1, 2, 3], [4, 5])", "[[1, 4], [2, 5]]"},
+		{`zip([1, 2], ["a", "b"], [true, false])`, `[[1, "a", true], [2, "b", false]]`},
+		{"flatten([1, [2, [3, [4]]], []])", "[1, 2, 3, 4]"},
+		{"let a = [1]; flatten([a, [a]])", "[1, 1]"},
+		{"uniq([1, 2, 1, 3, 2])", "[1, 2, 3]"},
+		{`uniq(["a", [1], "a", [1], nil, nil])`, `["a", [1], nil]`},
+		{"range(4)", "[0, 1, 2, 3]"},
+		{"range(2, 5)", "[2, 3, 4]"},
+		{"range(0, 10, 3)", "[0, 3, 6, 9]"},
+		{"range(3, 0, -1)", "[3, 2, 1]"},
+		{"range(5, 1)", "[]"},
+		{"range(0, 10, -1)", "[]"},
+		{"range(9223372036854775800, 9223372036854775807, 5)", "[9223372036854775800, 9223372036854775805]"},
+		{"range(9223372036854775805, 9223372036854775807)", "[9223372036854775805, 9223372036854775806]"},
+		{"range(-9223372036854775800, -9223372036854775808, -5)", "[-9223372036854775800, -9223372036854775805]"},
+		{"range(-9223372036854775807 - 1, -9223372036854775806)", "[-9223372036854775808, -9223372036854775807]"},
+		{"range(-9223372036854775807 - 1, 9223372036854775807, 9223372036854775807)", "[-9223372036854775808, -1, 9223372036854775806]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := eval(tt.input)
+		assert.Equal(t, tt.expected, evaluated.Inspect(), tt.input)
+	}
+}
+
+func TestHigherOrderBuiltinsErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"map([1])", "wrong number of arguments. got=1, want=2"},
+		{"map(1, len)", "first argument to `map` must be ARRAY, got INTEGER"},
+		{"filter([1], 1)", "second argument to `filter` must be FUNCTION, got INTEGER"},
+		{"map([1, 2], fun(x) { x + true })", "type mismatch: INTEGER + BOOLEAN"},
+		{"reduce([1], len)", "wrong number of arguments. got=2, want=3"},
+		{"reduce([1], 0, 1)", "third argument to `reduce` must be FUNCTION, got INTEGER"},
+		{`sort([1, "a"])`, "can't compare INTEGER with STRING"},
+		{"sort([true, false])", "can't compare BOOLEAN with BOOLEAN"},
+		{"zip([1])", "wrong number of arguments. got=1, want at least 2"},
+		{"zip([1], 2)", "arguments to `zip` must be ARRAY, got INTEGER"},
+		{"flatten(1)", "argument to `flatten` must be ARRAY, got INTEGER"},
+		{"let a = [1]; a[0] = [a]; flatten(a)", "can't flatten an array that contains itself"},
+		{"range()", "wrong number of arguments. got=0, want=1..3"},
+		{`range("1")`, "arguments to `range` must be INTEGER, got STRING"},
+		{"range(1, 5, 0)", "step for `range` can't be 0"},
+		{"range(0, 9223372036854775807)", "`range` can't have more than 16777216 elements, got 9223372036854775807"},
+		{"range(-9223372036854775807 - 1, 9223372036854775807)", "`range` can't have more than 16777216 elements, got 18446744073709551615"},
+		{"range(16777217)", "`range` can't have more than 16777216 elements, got 16777217"},
+	}
+
+	for _, tt := range tests {
+		evaluated := eval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		assert.True(t, ok, tt.input)
+		assert.Equal(t, tt.expected, errObj.Message)
+	}
+}
+
+func TestBindingsShadowBuiltins(t *testing.T) {
+	evaluated := eval(`
+	let map = fun(arr, f) { "custom map" }
+	map([1], fun(x) { x })`)
+
+	assertStringObject(t, evaluated, "custom map")
+}
+
+func TestStringBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`split("a,b,c", ",")`, `["a", "b", "c"]`},
+		{`split("a, b", ", ")`, `["a", "b"]`},
+		{`split("abc", "")`, `["a", "b", "c"]`},
+		{`split("", ",")`, `[""]`},
+		{`join(["a", "b", "c"], ", ")`, `"a, b, c"`},
+		{`join([], ", ")`, `""`},
+		{"trim(\"\t lainoa  \")", `"lainoa"`},
+		{`upper("lainoa")`, `"LAINOA"`},
+		{`lower("LaiNoa")`, `"lainoa"`},
+		{`upper("ñandú")`, `"ÑANDÚ"`},
+		{`contains("lainoa", "ino")`, "true"},
+		{`contains("lainoa", "x")`, "false"},
+		{`starts_with("lainoa", "lai")`, "true"},
+		{`starts_with("lainoa", "noa")`, "false"},
+		{`ends_with("lainoa", "noa")`, "true"},
+		{`replace("a-b-c", "-", "+")`, `"a+b+c"`},
+		{`index_of("lainoa", "noa")`, "3"},
+		{`index_of("lainoa", "x")`, "-1"},
+		{`index_of("ñandú", "dú")`, "3"},
+		{`repeat("ab", 3)`, `"ababab"`},
+		{`repeat("ab", 0)`, `""`},
+		{`chars("año")`, `["a", "ñ", "o"]`},
+		{`pad_left("7", 3)`, `"  7"`},
+		{`pad_left("7", 3, "0")`, `"007"`},
+		{`pad_left("lainoa", 3)`, `"lainoa"`},
+		{`pad_right("ñ", 3, ".")`, `"ñ.."`},
+		{`pad_right("a", 6, "xy")`, `"axyxyx"`},
+		{`"a b" |> split(" ") |> map(upper) |> join("-")`, `"A-B"`},
+	}
+
+	for _, tt := range tests {
+		evaluated := eval(tt.input)
+		assert.Equal(t, tt.expected, evaluated.Inspect(), tt.input)
+	}
+}
+
+func TestStringBuiltinsErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`upper(1)`, "argument to `upper` must be STRING, got INTEGER"},
+		{`upper("a", "b")`, "wrong number of arguments. got=2, want=1"},
+		{`split("a", 1)`, "second argument to `split` must be STRING, got INTEGER"},
+		{`replace("a", "b")`, "wrong number of arguments. got=2, want=3"},
+		{`replace("a", "b", nil)`, "third argument to `replace` must be STRING, got NIL"},
+		{`join("a", ",")`, "first argument to `join` must be ARRAY, got STRING"},
+		{`join([1, 2], ",")`, "elements to `join` must be STRING, got INTEGER"},
+		{`repeat("a", -1)`, "second argument to `repeat` can't be negative, got -1"},
+		{`repeat("ab", 9223372036854775807)`, "result of `repeat` can't be longer than 1073741824 bytes"},
+		{`repeat("a", 1073741825)`, "result of `repeat` can't be longer than 1073741824 bytes"},
+		{`pad_left("a", 9223372036854775807)`, "result of `pad_left` can't be longer than 1073741824 bytes"},
+		{`pad_right("a", 1073741824, "ab")`, "result of `pad_right` can't be longer than 1073741824 bytes"},
+		{`pad_left("a", "3")`, "second argument to `pad_left` must be INTEGER, got STRING"},
+		{`pad_right("a", 3, "")`, "third argument to `pad_right` can't be empty"},
+	}
+
+	for _, tt := range tests {
+		evaluated := eval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		assert.True(t, ok, tt.input)
+		assert.Equal(t, tt.expected, errObj.Message)
+	}
+}
+
+func TestUnicodeStrings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let izena = "Iñaki"; izena`, `"Iñaki"`},
+		{`let año = 2020; año`, "2020"},
+		{`"ñandú"[0]`, `"ñ"`},
+		{`"ñandú"[-1]`, `"ú"`},
+		{`"ñandú"[1:4]`, `"and"`},
+		{`"€uro"[:1]`, `"€"`},
+		{`bytes("a")`, "[97]"},
+		{`bytes("ñ")`, "[195, 177]"},
+		{`len(bytes("ñandú"))`, "7"},
+	}
+
+	for _, tt := range tests {
+		evaluated := eval(tt.input)
+		assert.Equal(t, tt.expected, evaluated.Inspect(), tt.input)
+	}
+}
+
+func TestHashes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{}`, `{}`},
+		{`{"one": 1, "two": 1 + 1, 3: true, false: nil}`, `{"one": 1, "two": 2, 3: true, false: nil}`},
+		{`let key = "k"; {key: 1}`, `{"k": 1}`},
+		{`{"one": 1}["one"]`, "1"},
+		{`{"one": 1}["two"]`, "nil"},
+		{`{1: "one"}[1]`, `"one"`},
+		{`{1: "one"}["1"]`, "nil"},
+		{`let h = {"a": 1}; h["b"] = 2; h`, `{"a": 1, "b": 2}`},
+		{`let h = {"a": 1, "b": 2}; h["a"] = 3; h`, `{"a": 3, "b": 2}`},
+		{`let h = {"a": 1}; h["a"] += 2; h["a"]`, "3"},
+		{`let h = {"a": 1}; let same = h; h["b"] = 2; same`, `{"a": 1, "b": 2}`},
+		{`len({"a": 1, "b": 2})`, "2"},
+		{`keys({"b": 1, "a": 2})`, `["b", "a"]`},
+		{`uniq([{"a": 1}, {"a": 1}, {"a": 2}])`, `[{"a": 1}, {"a": 2}]`},
+	}
+
+	for _, tt := range tests {
+		evaluated := eval(tt.input)
+		assert.Equal(t, tt.expected, evaluated.Inspect(), tt.input)
+	}
+}
+
+func TestHashErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{[1]: 1}`, "unusable as hash key: ARRAY"},
+		{`{"a": 1}[[1]]`, "unusable as hash key: ARRAY"},
+		{`let h = {}; h[fun() {}] = 1`, "unusable as hash key: FUNCTION"},
+		{`let h = {}; h["a"] += 1`, "type mismatch: NIL + INTEGER"},
+		{`keys([1])`, "argument to `keys` must be HASH, got ARRAY"},
+	}
+
+	for _, tt := range tests {
+		evaluated := eval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		assert.True(t, ok, tt.input)
+		assert.Equal(t, tt.expected, errObj.Message)
+	}
+}
+
+func TestJSONParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`1`, "1"},
+		{`-42`, "-42"},
+		{`99999999999999999999`, "99999999999999999999"},
+		{`true`, "true"},
+		{`null`, "nil"},
+		{`[]`, "[]"},
+		{`{}`, "{}"},
+		{`"lainoa"`, `"lainoa"`},
+		{`"a \"quote\" \u00f1"`, `"a "quote" ñ"`},
+		{
+			`{"name": "lainoa", "tags": [1, true, null], "nested": {"b": 1, "a": 2}}`,
+			`{"name": "lainoa", "tags": [1, true, nil], "nested": {"b": 1, "a": 2}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		evaluated := builtinJSONParse(&object.String{Value: tt.input})
+		assert.Equal(t, tt.expected, evaluated.Inspect(), tt.input)
+	}
+}
+
+func TestJSONParseFromLainoa(t *testing.T) {
+	evaluated := eval(`json_parse("[1, [2, 3]]")[1][0]`)
+
+	assertIntegerObject(t, evaluated, 2)
+}
+
+func TestJSONParseErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`[1, 2.5]`, "invalid JSON at line 1, column 5: number 2.5 is not supported, only integers are"},
+		{`1e3`, "invalid JSON at line 1, column 1: number 1e3 is not supported, only integers are"},
+		{`[1] [2]`, "invalid JSON at line 1, column 5: unexpected data after the JSON value"},
+		{"{}\n  1", "invalid JSON at line 2, column 3: unexpected data after the JSON value"},
+	}
+
+	for _, tt := range tests {
+		evaluated := builtinJSONParse(&object.String{Value: tt.input})
+
+		errObj, ok := evaluated.(*object.Error)
+		assert.True(t, ok, tt.input)
+		assert.Equal(t, tt.expected, errObj.Message)
+	}
+}
+
+func TestJSONParseSyntaxErrorPositions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{``, "invalid JSON at line 1, column 1: "},
+		{`[1, 2`, "invalid JSON at line 1, column 6: "},
+		{`{"a" 1}`, "invalid JSON at line 1, column 6: "},
+		{"{\n  \"a\": 1,\n  \"b\": }", "invalid JSON at line 3, column 8: "},
+		{`["ñ", x]`, "invalid JSON at line 1, column 7: "},
+	}
+
+	for _, tt := range tests {
+		evaluated := builtinJSONParse(&object.String{Value: tt.input})
+
+		errObj, ok := evaluated.(*object.Error)
+		assert.True(t, ok, tt.input)
+		assert.True(t, strings.HasPrefix(errObj.Message, tt.expected), errObj.Message)
+	}
+}
+
+func TestSelfContainingValues(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let h = {"a": 1}; h["self"] = h; h`, `{"a": 1, "self": {...}}`},
+		{`let a = [1]; a[0] = a; [a, a]`, `[[[...]], [[...]]]`},
+		{`let a = [1]; let h = {"a": a}; a[0] = h; a`, `[{"a": [...]}]`},
+		{`let a = [1]; a[0] = a; let b = [1]; b[0] = b; uniq([a, b])`, `[[[...]]]`},
+		{`let a = [1]; a[0] = a; let b = [2]; b[0] = [b]; uniq([a, b])`, `[[[...]]]`},
+		{`let h = {}; h["self"] = h; let g = {}; g["self"] = 1; uniq([h, g])`, `[{"self": {...}}, {"self": 1}]`},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, eval(tt.input).Inspect(), tt.input)
+	}
+}
+
+func TestJSONStringify(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`json_stringify(1)`, `1`},
+		{`json_stringify(nil)`, `null`},
+		{`json_stringify("a <b> & ñ")`, `"a <b> & ñ"`},
+		{`json_stringify([1, "two", false, nil, []])`, `[1,"two",false,null,[]]`},
+		{`json_stringify({"b": 1, "a": {"c": [1]}})`, `{"b":1,"a":{"c":[1]}}`},
+		{`json_stringify({"a": [1, 2]}, 2)`, "{\n  \"a\": [\n    1,\n    2\n  ]\n}"},
+		{`json_stringify(json_stringify({"a": 1}))`, `"{\"a\":1}"`},
+		{`{"a": [1, {"b": 2}]} |> json_stringify |> json_parse |> json_stringify`, `{"a":[1,{"b":2}]}`},
+		{`let a = [1]; json_stringify([a, {"b": a}])`, `[[1],{"b":[1]}]`},
+	}
+
+	for _, tt := range tests {
+		evaluated := eval(tt.input)
+		str, ok := evaluated.(*object.String)
+		assert.True(t, ok, tt.input)
+		assert.Equal(t, tt.expected, str.Value)
+	}
+}
+
+func TestJSONStringifyErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`json_stringify(fun(x) { x })`, "can't serialize FUNCTION at $ to JSON"},
+		{`json_stringify({"users": [1, len]})`, `can't serialize BUILTIN at $["users"][1] to JSON`},
+		{`json_stringify({"a": {1: 2}})`, `can't serialize 1 at $["a"] to JSON, hash keys must be STRING, got INTEGER`},
+		{`json_stringify(1, "2")`, "second argument to `json_stringify` must be INTEGER, got STRING"},
+		{`json_stringify(1, 9223372036854775807)`, "second argument to `json_stringify` can't be more than 10, got 9223372036854775807"},
+		{`let h = {}; h["self"] = h; json_stringify(h)`, `can't serialize HASH at $["self"] to JSON, it contains itself`},
+		{`let a = [1]; let h = {"a": a}; a[0] = [h]; json_stringify(a)`, `can't serialize ARRAY at $[0][0]["a"] to JSON, it contains itself`},
+		{`json_stringify()`, "wrong number of arguments. got=0, want=1..2"},
+	}
+
+	for _, tt := range tests {
+		evaluated := eval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		assert.True(t, ok, tt.input)
+		assert.Equal(t, tt.expected, errObj.Message)
+	}
+}
+
+func withFilesystem(t *testing.T, readOnly bool) (string, func()) {
+	dir, err := ioutil.TempDir("", "lainoa")
+	assert.Nil(t, err)
+	assert.Nil(t, SetFilesystem(Filesystem{Roots: []string{dir}, ReadOnly: readOnly}))
+
+	return dir, func() {
+		SetFilesystem(Filesystem{})
+		os.RemoveAll(dir)
+	}
+}
+
+func TestFilesystemBuiltins(t *testing.T) {
+	dir, cleanup := withFilesystem(t, false)
+	defer cleanup()
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "data.txt"), []byte("kaixo"), 0644))
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`read_file("DIR/data.txt")`, `"kaixo"`},
+		{`write_file("DIR/out.txt", "a"); append_file("DIR/out.txt", "b"); read_file("DIR/out.txt")`, `"ab"`},
+		{`write_file("DIR/out.txt", "c"); read_file("DIR/out.txt")`, `"c"`},
+		{`write_file("DIR/new.txt", "")`, "nil"},
+		{`list_dir("DIR")`, `["data.txt", "new.txt", "out.txt"]`},
+		{`exists("DIR/out.txt")`, "true"},
+		{`remove("DIR/out.txt"); exists("DIR/out.txt")`, "false"},
+		{`exists("DIR/sub/../data.txt")`, "true"},
+	}
+
+	for _, tt := range tests {
+		evaluated := eval(strings.Replace(tt.input, "DIR", dir, -1))
+
+		assert.Equal(t, tt.expected, evaluated.Inspect(), tt.input)
+	}
+}
+
+func TestFilesystemBuiltinsErrors(t *testing.T) {
+	dir, cleanup := withFilesystem(t, false)
+	defer cleanup()
+	outside, err := ioutil.TempDir("", "lainoa")
+	assert.Nil(t, err)
+	defer os.RemoveAll(outside)
+	assert.Nil(t, os.Symlink(outside, filepath.Join(dir, "link")))
+	assert.Nil(t, os.Symlink(filepath.Join(outside, "created.txt"), filepath.Join(dir, "dangling")))
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`read_file("DIR/missing.txt")`, "`read_file` failed for \"DIR/missing.txt\": no such file or directory"},
+		{`remove("DIR/missing.txt")`, "`remove` failed for \"DIR/missing.txt\": no such file or directory"},
+		{`list_dir("DIR/missing")`, "`list_dir` failed for \"DIR/missing\": no such file or directory"},
+		{`read_file("DIR/../secret")`, "access to \"DIR/../secret\" is outside the allowed directories"},
+		{`write_file("DIR/link/file.txt", "a")`, "access to \"DIR/link/file.txt\" is outside the allowed directories"},
+		{`write_file("DIR/dangling", "a")`, "invalid path \"DIR/dangling\": symlink to a missing file"},
+		{`append_file("DIR/dangling/file.txt", "a")`, "invalid path \"DIR/dangling/file.txt\": symlink to a missing file"},
+		{`read_file(1)`, "first argument to `read_file` must be STRING, got INTEGER"},
+		{`write_file("DIR/file.txt", 1)`, "second argument to `write_file` must be STRING, got INTEGER"},
+		{`exists()`, "wrong number of arguments. got=0, want=1"},
+	}
+
+	for _, tt := range tests {
+		evaluated := eval(strings.Replace(tt.input, "DIR", dir, -1))
+
+		errObj, ok := evaluated.(*object.Error)
+		assert.True(t, ok, evaluated.Inspect())
+		assert.Equal(t, strings.Replace(tt.expected, "DIR", dir, -1), errObj.Message)
+	}
+
+	_, err = os.Lstat(filepath.Join(outside, "created.txt"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestFilesystemSwappedSymlink(t *testing.T) {
+	dir, cleanup := withFilesystem(t, false)
+	defer cleanup()
+	outside, err := ioutil.TempDir("", "lainoa")
+	assert.Nil(t, err)
+	defer os.RemoveAll(outside)
+	secret := filepath.Join(outside, "secret.txt")
+	assert.Nil(t, ioutil.WriteFile(secret, []byte("kaixo"), 0644))
+
+	path, errObj := pathArg("write_file", []object.Object{&object.String{Value: filepath.Join(dir, "out.txt")}}, 1, true)
+	assert.Nil(t, errObj)
+	// the path is replaced after it was checked
+	assert.Nil(t, os.Symlink(secret, path))
+
+	for _, flag := range []int{os.O_WRONLY, os.O_WRONLY | os.O_APPEND} {
+		file, err := openWritable(path, flag)
+		assert.NotNil(t, err)
+		assert.Nil(t, file)
+	}
+
+	data, err := ioutil.ReadFile(secret)
+	assert.Nil(t, err)
+	assert.Equal(t, "kaixo", string(data))
+}
+
+func TestFilesystemCapabilities(t *testing.T) {
+	dir, cleanup := withFilesystem(t, true)
+	defer cleanup()
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "data.txt"), []byte("kaixo"), 0644))
+
+	assertStringObject(t, eval(`read_file("`+dir+`/data.txt")`), "kaixo")
+
+	evaluated := eval(`write_file("` + dir + `/data.txt", "agur")`)
+	errObj, ok := evaluated.(*object.Error)
+	assert.True(t, ok)
+	assert.Equal(t, "can't use `write_file`, filesystem access is read-only", errObj.Message)
+
+	SetFilesystem(Filesystem{})
+	evaluated = eval(`read_file("` + dir + `/data.txt")`)
+	errObj, ok = evaluated.(*object.Error)
+	assert.True(t, ok)
+	assert.Equal(t, "can't use `read_file`, filesystem access is disabled", errObj.Message)
+}
+
+func TestProcessBuiltins(t *testing.T) {
+	SetArgs([]string{"one", "two"})
+	defer SetArgs(nil)
+	os.Setenv("LAINOA_TEST_VAR", "kaixo")
+	defer os.Unsetenv("LAINOA_TEST_VAR")
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`args()`, `["one", "two"]`},
+		{`env("LAINOA_TEST_VAR")`, `"kaixo"`},
+		{`env("LAINOA_MISSING_VAR")`, "nil"},
+		{`env(1)`, "ERROR: argument to `env` must be STRING, got INTEGER"},
+		{`exit("1")`, "ERROR: argument to `exit` must be INTEGER, got STRING"},
+		{`exit(256)`, "ERROR: exit status must be between 0 and 255, got 256"},
+	}
+
+	for _, tt := range tests {
+		evaluated := eval(tt.input)
+
+		assert.Equal(t, tt.expected, evaluated.Inspect(), tt.input)
+	}
+}
+
+func TestExit(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{`exit()`, 0},
+		{`exit(3); puts("unreachable")`, 3},
+		{`fun stop(code) { exit(code); 1 }; let x = stop(2); x + 1`, 2},
+		{`map([1, 2], fun(n) { if (n == 2) { exit(n) }; n })`, 2},
+	}
+
+	for _, tt := range tests {
+		evaluated := eval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		assert.True(t, ok, tt.input)
+		assert.True(t, errObj.Exit)
+		assert.Equal(t, tt.expected, errObj.ExitCode)
+	}
+}
+
+func TestAssertionBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`assert(1 == 1)`, "nil"},
+		{`assert(1 == 2)`, "ERROR: assertion failed"},
+		{`assert(1 == 2, "math is broken")`, "ERROR: assertion failed: math is broken"},
+		{`assert(1)`, "ERROR: first argument to `assert` must be BOOLEAN, got INTEGER"},
+		{`assert_eq([1, {"a": 2}], [1, {"a": 2}])`, "nil"},
+		{`assert_eq(len([1]), 2)`, "ERROR: assertion failed: expected 2, got 1"},
+		{`3 |> assert_eq(2, "piped")`, "ERROR: assertion failed: piped: expected 2, got 3"},
+		{`assert_error(fun() { 1 + "a" })`, "nil"},
+		{`assert_error(fun() { 1 + "a" }, "type mismatch")`, "nil"},
+		{`assert_error(fun() { 1 + "a" }, "unknown")`,
+			`ERROR: assertion failed: expected an error containing "unknown", got "type mismatch: INTEGER + STRING"`},
+		{`assert_error(fun() { 1 })`, "ERROR: assertion failed: expected an error, got 1"},
+		{`assert_error(1)`, "ERROR: first argument to `assert_error` must be a function, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := eval(tt.input)
+
+		assert.Equal(t, tt.expected, evaluated.Inspect(), tt.input)
+	}
+}
+
+func TestBuiltinErrorsHaveLocation(t *testing.T) {
+	evaluated := eval("let check = fun(n) {\n  assert_eq(n, 2)\n}\n\ncheck(1)")
+
+	errObj, ok := evaluated.(*object.Error)
+	assert.True(t, ok)
+	assert.Equal(t, "/path/to/file", errObj.Location.File)
+	assert.Equal(t, 2, errObj.Location.Line)
+}
+
+func TestProfiledFunctionNames(t *testing.T) {
+	p := profiler.New()
+	SetProfiler(p)
+	defer SetProfiler(nil)
+
+	eval(`let double = fun(x) { x * 2 }
+fun triple(x) { x * 3 }
+map([1, 2], fun(x) { double(x) |> triple })`)
+
+	names := []string{}
+	for _, stats := range p.Stats() {
+		names = append(names, stats.Function.String())
+	}
+	sort.Strings(names)
+
+	assert.Equal(t, []string{
+		"anonymous (/path/to/file:3)",
+		"double (/path/to/file:1)",
+		"map (builtin)",
+		"triple (/path/to/file:2)",
+	}, names)
+}
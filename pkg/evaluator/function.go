@@ -3,16 +3,51 @@ package evaluator
 import (
 	"github.com/uesteibar/lainoa/pkg/ast"
 	"github.com/uesteibar/lainoa/pkg/object"
+	"github.com/uesteibar/lainoa/pkg/token"
 )
 
 func evalFunctionLiteral(fun *ast.FunctionLiteral, env *object.Environment) object.Object {
 	return &object.Function{
 		Parameters: fun.Parameters,
+		Defaults:   fun.Defaults,
+		Rest:       fun.Rest,
 		Body:       fun.Body,
 		Env:        env,
+		Literal:    fun,
 	}
 }
 
+func evalFunctionDeclaration(decl *ast.FunctionDeclaration, env *object.Environment) object.Object {
+	// declarations are usually bound already, when hoisting their block
+	if fn, ok := env.Get(decl.Name.Value); ok {
+		return fn
+	}
+
+	return declareFunction(decl, env)
+}
+
+func declareFunction(decl *ast.FunctionDeclaration, env *object.Environment) object.Object {
+	return env.Set(decl.Name.Value, evalFunctionLiteral(decl.Function, env))
+}
+
+// hoistFunctionDeclarations binds every function declared in a block before
+// running it, so functions can be called before the line declaring them and
+// can call each other regardless of the order they're declared in.
+func hoistFunctionDeclarations(statements []ast.Statement, env *object.Environment) *object.Error {
+	for _, stmt := range statements {
+		decl, ok := stmt.(*ast.FunctionDeclaration)
+		if !ok {
+			continue
+		}
+
+		if err, ok := declareFunction(decl, env).(*object.Error); ok {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func evalFunctionCall(call *ast.CallExpression, env *object.Environment) object.Object {
 	fun := Eval(call.Function, env)
 	if object.IsError(fun) {
@@ -24,39 +59,50 @@ func evalFunctionCall(call *ast.CallExpression, env *object.Environment) object.
 		return err
 	}
 
-	return applyFunction(fun, args)
+	return locateError(fun, applyFunction(fun, args), call.Token.Metadata)
+}
+
+// locateError records where a builtin was called when it fails, so errors
+// like failed assertions can point at the line that caused them.
+func locateError(fn object.Object, res object.Object, location token.Metadata) object.Object {
+	if _, ok := fn.(*object.Builtin); !ok {
+		return res
+	}
+	if err, ok := res.(*object.Error); ok && err.Location.Line == 0 {
+		err.Location = location
+	}
+
+	return res
 }
 
 func applyFunction(fn object.Object, args []object.Object) object.Object {
+	if profile != nil {
+		if described, ok := profiledFunction(fn); ok {
+			profile.Enter(described)
+			defer profile.Exit()
+		}
+	}
+
 	switch fn := fn.(type) {
 	case *object.Function:
-		env, err := envWithArgs(fn.Parameters, args, fn.Env)
-		if err != nil {
-			return err
-		}
-		if len(args) < len(fn.Parameters) {
-			return curryFunction(fn, env, args)
+		if tracer != nil {
+			tracer.Call(fn)
+			defer tracer.Return()
 		}
-		if len(args) > len(fn.Parameters) {
-			return tooManyArgumentsError(args, fn.Parameters)
-		}
-
-		evaluated := Eval(fn.Body, env)
-		return unwrapReturnValue(evaluated)
+		return callFunction(fn, fn.Parameters, fn.Env, args)
 	case *object.CurriedFunction:
-		env, err := envWithArgs(fn.ParametersLeft, args, fn.Env)
-		if err != nil {
-			return err
-		}
-		if len(args) < len(fn.ParametersLeft) {
-			return recurryFunction(fn, env, args)
+		if tracer != nil {
+			tracer.Call(fn.Fn)
+			defer tracer.Return()
 		}
-		if len(args) > len(fn.ParametersLeft) {
-			return tooManyArgumentsError(args, fn.ParametersLeft)
+		return callFunction(fn.Fn, fn.ParametersLeft, fn.Env, args)
+	case *object.ComposedFunction:
+		res := applyFunction(fn.First, args)
+		if object.IsError(res) {
+			return res
 		}
 
-		evaluated := Eval(fn.Fn.Body, env)
-		return unwrapReturnValue(evaluated)
+		return applyFunction(fn.Second, []object.Object{res})
 	case *object.Builtin:
 		return fn.Fn(args...)
 
@@ -65,19 +111,42 @@ func applyFunction(fn object.Object, args []object.Object) object.Object {
 	}
 }
 
-func curryFunction(fn *object.Function, env *object.Environment, args []object.Object) *object.CurriedFunction {
-	return &object.CurriedFunction{
-		Fn:             fn,
-		Env:            env,
-		ParametersLeft: fn.Parameters[len(args):len(fn.Parameters)],
+// callFunction binds args to the parameters of fn that are still unbound,
+// currying it when there aren't enough arguments for its required parameters.
+// Parameters with default values never trigger currying: when all the
+// required ones are there, the function runs and the defaults fill the gaps.
+func callFunction(fn *object.Function, params []*ast.Identifier, outer *object.Environment, args []object.Object) object.Object {
+	env, err := envWithArgs(params, args, outer)
+	if err != nil {
+		return err
+	}
+	if len(args) < fn.RequiredParameters(params) {
+		return curryFunction(fn, env, params[len(args):])
+	}
+	if len(args) > len(params) && fn.Rest == nil {
+		return tooManyArgumentsError(fn, args, params)
+	}
+
+	if len(args) < len(params) {
+		if err := bindDefaults(fn, params[len(args):], env); err != nil {
+			return err
+		}
 	}
+	if fn.Rest != nil {
+		if err := bindRest(fn.Rest, params, args, env); err != nil {
+			return err
+		}
+	}
+
+	evaluated := Eval(fn.Body, env)
+	return unwrapReturnValue(evaluated)
 }
 
-func recurryFunction(cur *object.CurriedFunction, env *object.Environment, args []object.Object) *object.CurriedFunction {
+func curryFunction(fn *object.Function, env *object.Environment, paramsLeft []*ast.Identifier) *object.CurriedFunction {
 	return &object.CurriedFunction{
-		Fn:             cur.Fn,
+		Fn:             fn,
 		Env:            env,
-		ParametersLeft: cur.ParametersLeft[len(args):len(cur.ParametersLeft)],
+		ParametersLeft: paramsLeft,
 	}
 }
 
@@ -98,6 +167,36 @@ func envWithArgs(params []*ast.Identifier, args []object.Object, env *object.Env
 	return newEnv, nil
 }
 
+// bindDefaults evaluates default values in the function's own environment,
+// so they can refer to the parameters before them.
+func bindDefaults(fn *object.Function, params []*ast.Identifier, env *object.Environment) *object.Error {
+	for _, param := range params {
+		val := Eval(fn.Defaults[param.Value], env)
+		if err, ok := val.(*object.Error); ok {
+			return err
+		}
+
+		if err, ok := env.Set(param.Value, val).(*object.Error); ok {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func bindRest(rest *ast.Identifier, params []*ast.Identifier, args []object.Object, env *object.Environment) *object.Error {
+	elements := []object.Object{}
+	if len(args) > len(params) {
+		elements = append(elements, args[len(params):]...)
+	}
+
+	if err, ok := env.Set(rest.Value, &object.Array{Elements: elements}).(*object.Error); ok {
+		return err
+	}
+
+	return nil
+}
+
 func unwrapReturnValue(obj object.Object) object.Object {
 	if returnValue, ok := obj.(*object.ReturnValue); ok {
 		return returnValue.Value
@@ -106,6 +205,10 @@ func unwrapReturnValue(obj object.Object) object.Object {
 	return obj
 }
 
-func tooManyArgumentsError(args []object.Object, parameters []*ast.Identifier) *object.Error {
+func tooManyArgumentsError(fn *object.Function, args []object.Object, parameters []*ast.Identifier) *object.Error {
+	if fn.Literal.Name != "" {
+		return object.NewError("`%s` expected %d arguments, got %d", fn.Literal.Name, len(parameters), len(args))
+	}
+
 	return object.NewError("expected %d arguments, got %d", len(parameters), len(args))
 }
@@ -0,0 +1,71 @@
+package evaluator
+
+import (
+	"github.com/uesteibar/lainoa/pkg/ast"
+	"github.com/uesteibar/lainoa/pkg/object"
+)
+
+func evalHashLiteral(hash *ast.HashLiteral, env *object.Environment) object.Object {
+	res := object.NewHash()
+
+	for i, keyExp := range hash.Keys {
+		key := Eval(keyExp, env)
+		if object.IsError(key) {
+			return key
+		}
+		hashable, ok := key.(object.Hashable)
+		if !ok {
+			return unusableHashKeyError(key)
+		}
+
+		value := Eval(hash.Values[i], env)
+		if object.IsError(value) {
+			return value
+		}
+
+		res.Set(hashable, value)
+	}
+
+	return res
+}
+
+func evalHashIndex(hash *object.Hash, key object.Object) object.Object {
+	hashable, ok := key.(object.Hashable)
+	if !ok {
+		return unusableHashKeyError(key)
+	}
+
+	if value, ok := hash.Get(hashable); ok {
+		return value
+	}
+
+	return NIL
+}
+
+// evalHashIndexAssign adds the key when it's not there yet. Like arrays,
+// hashes are updated in place.
+func evalHashIndexAssign(hash *object.Hash, key object.Object, operator string, val object.Object) object.Object {
+	hashable, ok := key.(object.Hashable)
+	if !ok {
+		return unusableHashKeyError(key)
+	}
+
+	if operator != "" {
+		current, ok := hash.Get(hashable)
+		if !ok {
+			current = NIL
+		}
+
+		val = evalInfixOperation(current, operator, val)
+		if object.IsError(val) {
+			return val
+		}
+	}
+
+	hash.Set(hashable, val)
+	return val
+}
+
+func unusableHashKeyError(key object.Object) *object.Error {
+	return object.NewError("unusable as hash key: %s", key.Type())
+}
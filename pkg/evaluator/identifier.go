@@ -6,11 +6,13 @@ import (
 )
 
 func evalIdentifier(ident *ast.Identifier, env *object.Environment) object.Object {
-	if val, exists := builtins[ident.Value]; exists {
+	// bindings come first, so adding new builtins never breaks programs
+	// already using the same names
+	if val, exists := env.Get(ident.Value); exists {
 		return val
 	}
 
-	if val, exists := env.Get(ident.Value); exists {
+	if val, exists := builtins[ident.Value]; exists {
 		return val
 	}
 
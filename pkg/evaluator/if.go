@@ -11,6 +11,10 @@ func evalIfExpression(ifexp *ast.IfExpression, env *object.Environment) object.O
 		return condition
 	}
 
+	if cover != nil {
+		cover.RecordBranch(ifexp, isTruthy(condition))
+	}
+
 	if isTruthy(condition) {
 		return Eval(ifexp.Consequence, object.NewEnclosedEnvironment(env))
 	} else if ifexp.Alternative != nil {
@@ -18,6 +18,10 @@ func evalIndexOperation(index *ast.IndexExpression, env *object.Environment) obj
 	switch left := left.(type) {
 	case *object.Array:
 		return evalArrayIndex(left, i)
+	case *object.String:
+		return evalStringIndex(left, i)
+	case *object.Hash:
+		return evalHashIndex(left, i)
 	default:
 		return object.NewError("type %s doesn't support index operations", left.Type())
 	}
@@ -26,8 +30,8 @@ func evalIndexOperation(index *ast.IndexExpression, env *object.Environment) obj
 func evalArrayIndex(array *object.Array, i object.Object) object.Object {
 	switch i := i.(type) {
 	case *object.Integer:
-		if int(i.Value) < len(array.Elements) && i.Value >= 0 {
-			return array.Elements[i.Value]
+		if idx, ok := resolveIndex(i.Value, len(array.Elements)); ok {
+			return array.Elements[idx]
 		}
 
 		return NIL
@@ -35,3 +39,31 @@ func evalArrayIndex(array *object.Array, i object.Object) object.Object {
 		return object.NewError("expected %s as index for array, got %s", object.INTEGER_OBJECT, i.Type())
 	}
 }
+
+func evalStringIndex(str *object.String, i object.Object) object.Object {
+	switch i := i.(type) {
+	case *object.Integer:
+		chars := []rune(str.Value)
+		if idx, ok := resolveIndex(i.Value, len(chars)); ok {
+			return &object.String{Value: string(chars[idx])}
+		}
+
+		return NIL
+	default:
+		return object.NewError("expected %s as index for string, got %s", object.INTEGER_OBJECT, i.Type())
+	}
+}
+
+// resolveIndex turns negative indexes into positions counted from the end,
+// so -1 is the last element. It reports false when the index is out of range.
+func resolveIndex(i int64, length int) (int, bool) {
+	if i < 0 {
+		i += int64(length)
+	}
+
+	if i < 0 || i >= int64(length) {
+		return 0, false
+	}
+
+	return int(i), true
+}
@@ -2,6 +2,8 @@ package evaluator
 
 import (
 	"fmt"
+	"math"
+	"math/big"
 
 	"github.com/uesteibar/lainoa/pkg/ast"
 	"github.com/uesteibar/lainoa/pkg/object"
@@ -18,48 +20,54 @@ func evalInfix(infix *ast.InfixExpression, env *object.Environment) object.Objec
 		return right
 	}
 
+	return evalInfixOperation(left, infix.Operator, right)
+}
+
+// InfixOperation applies an infix operator to evaluated values, computing
+// the result like PrefixOperation does.
+func InfixOperation(left object.Object, operator string, right object.Object) object.Object {
+	return evalInfixOperation(left, operator, right)
+}
+
+func evalInfixOperation(left object.Object, operator string, right object.Object) object.Object {
 	switch {
+	case operator == token.COMPOSE:
+		return evalComposition(left, right)
 	case left.Type() == object.INTEGER_OBJECT && right.Type() == object.INTEGER_OBJECT:
 		left := left.(*object.Integer)
 		right := right.(*object.Integer)
-		return evalIntegerInfixExpression(left, infix.Operator, right)
+		return evalIntegerInfixExpression(left, operator, right)
 	case left.Type() == object.STRING_OBJECT && right.Type() == object.STRING_OBJECT:
 		left := left.(*object.String)
 		right := right.(*object.String)
-		return evalStringInfixExpression(left, infix.Operator, right)
-	case infix.Operator == token.EQ:
+		return evalStringInfixExpression(left, operator, right)
+	case operator == token.EQ:
 		return nativeBoolToBoolean(left == right)
-	case infix.Operator == token.NOT_EQ:
+	case operator == token.NOT_EQ:
 		return nativeBoolToBoolean(left != right)
 	case left.Type() != right.Type():
 		return object.NewError(
 			"type mismatch: %s %s %s",
-			left.Type(), infix.Operator, right.Type())
+			left.Type(), operator, right.Type())
 	default:
 		return object.NewError(
 			"unknown operator: %s %s %s",
-			left.Type(), infix.Operator, right.Type())
+			left.Type(), operator, right.Type())
 	}
 }
 
 func evalIntegerInfixExpression(left *object.Integer, operator string, right *object.Integer) object.Object {
 	switch operator {
-	case token.PLUS:
-		return &object.Integer{Value: left.Value + right.Value}
-	case token.MINUS:
-		return &object.Integer{Value: left.Value - right.Value}
-	case token.ASTERISK:
-		return &object.Integer{Value: left.Value * right.Value}
-	case token.SLASH:
-		return &object.Integer{Value: left.Value / right.Value}
+	case token.PLUS, token.MINUS, token.ASTERISK, token.SLASH:
+		return evalIntegerArithmetic(left, operator, right)
 	case token.LT:
-		return nativeBoolToBoolean(left.Value < right.Value)
+		return nativeBoolToBoolean(left.Cmp(right) < 0)
 	case token.GT:
-		return nativeBoolToBoolean(left.Value > right.Value)
+		return nativeBoolToBoolean(left.Cmp(right) > 0)
 	case token.EQ:
-		return nativeBoolToBoolean(left.Value == right.Value)
+		return nativeBoolToBoolean(left.Cmp(right) == 0)
 	case token.NOT_EQ:
-		return nativeBoolToBoolean(left.Value != right.Value)
+		return nativeBoolToBoolean(left.Cmp(right) != 0)
 	default:
 		return object.NewError(
 			"unknown operator: %s %s %s",
@@ -67,6 +75,61 @@ func evalIntegerInfixExpression(left *object.Integer, operator string, right *ob
 	}
 }
 
+// evalIntegerArithmetic works with int64s while the result fits in one,
+// and with big.Ints when it doesn't.
+func evalIntegerArithmetic(left *object.Integer, operator string, right *object.Integer) object.Object {
+	if operator == token.SLASH && right.Big == nil && right.Value == 0 {
+		return object.NewError("division by zero")
+	}
+
+	if left.Big == nil && right.Big == nil {
+		if res, ok := int64Arithmetic(left.Value, operator, right.Value); ok {
+			return &object.Integer{Value: res}
+		}
+	}
+
+	res := new(big.Int)
+	switch operator {
+	case token.PLUS:
+		res.Add(left.BigInt(), right.BigInt())
+	case token.MINUS:
+		res.Sub(left.BigInt(), right.BigInt())
+	case token.ASTERISK:
+		res.Mul(left.BigInt(), right.BigInt())
+	case token.SLASH:
+		res.Quo(left.BigInt(), right.BigInt())
+	}
+
+	return object.NewBigInteger(res)
+}
+
+// int64Arithmetic returns the result of the operation and whether it fits
+// in an int64.
+func int64Arithmetic(left int64, operator string, right int64) (int64, bool) {
+	switch operator {
+	case token.PLUS:
+		res := left + right
+		return res, (right >= 0) == (res >= left)
+	case token.MINUS:
+		res := left - right
+		return res, (right >= 0) == (res <= left)
+	case token.ASTERISK:
+		if left == 0 || right == 0 {
+			return 0, true
+		}
+		if (left == -1 && right == math.MinInt64) || (right == -1 && left == math.MinInt64) {
+			return 0, false
+		}
+		res := left * right
+		return res, res/right == left
+	default:
+		if left == math.MinInt64 && right == -1 {
+			return 0, false
+		}
+		return left / right, true
+	}
+}
+
 func evalStringInfixExpression(left *object.String, operator string, right *object.String) object.Object {
 	switch operator {
 	case token.PLUS:
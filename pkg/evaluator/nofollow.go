@@ -0,0 +1,10 @@
+//go:build !windows
+// +build !windows
+
+package evaluator
+
+import "syscall"
+
+// noFollow makes opening a file fail when the last element of its path is a
+// symlink.
+const noFollow = syscall.O_NOFOLLOW
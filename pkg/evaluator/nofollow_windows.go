@@ -0,0 +1,5 @@
+package evaluator
+
+// noFollow is a no-op on Windows, where opened files are only checked once
+// they're open.
+const noFollow = 0
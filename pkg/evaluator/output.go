@@ -0,0 +1,13 @@
+package evaluator
+
+import (
+	"io"
+	"os"
+)
+
+var output io.Writer = os.Stdout
+
+// SetOutput sets where builtins like `puts` write to, stdout by default.
+func SetOutput(w io.Writer) {
+	output = w
+}
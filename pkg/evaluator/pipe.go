@@ -0,0 +1,87 @@
+package evaluator
+
+import (
+	"github.com/uesteibar/lainoa/pkg/ast"
+	"github.com/uesteibar/lainoa/pkg/object"
+	"github.com/uesteibar/lainoa/pkg/token"
+)
+
+// evalPipe passes the left value as the first argument of the call on the
+// right, so `list |> push(1)` is `push(list, 1)`. When the right side isn't a
+// call, it's called with the left value as its only argument. When it's a
+// composition, the value goes into its first function the same way, and the
+// result through the rest, so `x |> f(a) >> g` is `g(f(x, a))`.
+func evalPipe(pipe *ast.PipeExpression, env *object.Environment) object.Object {
+	left := Eval(pipe.Left, env)
+	if object.IsError(left) {
+		return left
+	}
+
+	steps := compositionSteps(pipe.Right)
+	first, args, location := steps[0], []object.Object{}, pipe.Token.Metadata
+	if call, ok := first.(*ast.CallExpression); ok {
+		first, location = call.Function, call.Token.Metadata
+	}
+
+	fn := Eval(first, env)
+	if object.IsError(fn) {
+		return fn
+	}
+
+	if call, ok := steps[0].(*ast.CallExpression); ok {
+		var err *object.Error
+		if args, err = evalExpressions(call.Arguments, env); err != nil {
+			return err
+		}
+	}
+
+	rest := make([]object.Object, len(steps)-1)
+	for i, step := range steps[1:] {
+		if rest[i] = Eval(step, env); object.IsError(rest[i]) {
+			return rest[i]
+		}
+		if err := evalComposition(fn, rest[i]); object.IsError(err) {
+			return err
+		}
+	}
+
+	res := locateError(fn, applyFunction(fn, append([]object.Object{left}, args...)), location)
+	for _, next := range rest {
+		if object.IsError(res) {
+			return res
+		}
+		res = locateError(next, applyFunction(next, []object.Object{res}), pipe.Token.Metadata)
+	}
+
+	return res
+}
+
+// compositionSteps returns the functions composed in exp, in the order
+// they're called, or exp alone when it isn't a composition.
+func compositionSteps(exp ast.Expression) []ast.Expression {
+	infix, ok := exp.(*ast.InfixExpression)
+	if !ok || infix.Operator != token.COMPOSE {
+		return []ast.Expression{exp}
+	}
+
+	return append(compositionSteps(infix.Left), infix.Right)
+}
+
+func evalComposition(first object.Object, second object.Object) object.Object {
+	for _, fn := range []object.Object{first, second} {
+		if !isCallable(fn) {
+			return object.NewError("can't compose %s, expected a function", fn.Type())
+		}
+	}
+
+	return &object.ComposedFunction{First: first, Second: second}
+}
+
+func isCallable(obj object.Object) bool {
+	switch obj.(type) {
+	case *object.Function, *object.CurriedFunction, *object.ComposedFunction, *object.Builtin:
+		return true
+	default:
+		return false
+	}
+}
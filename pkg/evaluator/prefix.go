@@ -1,6 +1,9 @@
 package evaluator
 
 import (
+	"math"
+	"math/big"
+
 	"github.com/uesteibar/lainoa/pkg/ast"
 	"github.com/uesteibar/lainoa/pkg/object"
 	"github.com/uesteibar/lainoa/pkg/token"
@@ -12,7 +15,14 @@ func evalPrefix(prefix *ast.PrefixExpression, env *object.Environment) object.Ob
 		return right
 	}
 
-	switch prefix.Token.Type {
+	return PrefixOperation(prefix.Operator, right)
+}
+
+// PrefixOperation applies a prefix operator to an evaluated value. It only
+// computes the result, without evaluating or recording anything, so tools
+// can use it to work out operations on literals before running a program.
+func PrefixOperation(operator string, right object.Object) object.Object {
+	switch operator {
 	case token.BANG:
 		return evalBangOperation(right)
 	case token.MINUS:
@@ -20,7 +30,7 @@ func evalPrefix(prefix *ast.PrefixExpression, env *object.Environment) object.Ob
 	case token.PLUS:
 		return evalPlusOperation(right)
 	default:
-		return object.NewError("unknown operator: %s%s", prefix.Operator, right.Type())
+		return object.NewError("unknown operator: %s%s", operator, right.Type())
 	}
 }
 
@@ -42,8 +52,8 @@ func evalPlusOperation(right object.Object) object.Object {
 		return object.NewError("unknown operator: +%s", right.Type())
 	}
 
-	val := right.(*object.Integer).Value
-	return &object.Integer{Value: val}
+	val := right.(*object.Integer)
+	return &object.Integer{Value: val.Value, Big: val.Big}
 }
 
 func evalMinusOperation(right object.Object) object.Object {
@@ -51,6 +61,10 @@ func evalMinusOperation(right object.Object) object.Object {
 		return object.NewError("unknown operator: -%s", right.Type())
 	}
 
-	val := right.(*object.Integer).Value
-	return &object.Integer{Value: -val}
+	val := right.(*object.Integer)
+	// -math.MinInt64 doesn't fit in an int64 either
+	if val.Big != nil || val.Value == math.MinInt64 {
+		return object.NewBigInteger(new(big.Int).Neg(val.BigInt()))
+	}
+	return &object.Integer{Value: -val.Value}
 }
@@ -0,0 +1,53 @@
+package evaluator
+
+import (
+	"github.com/uesteibar/lainoa/pkg/object"
+	"github.com/uesteibar/lainoa/pkg/profiler"
+)
+
+var (
+	profile      *profiler.Profiler
+	builtinNames map[*object.Builtin]string
+)
+
+// SetProfiler makes the evaluator measure every function call in p. Passing
+// nil turns profiling off.
+func SetProfiler(p *profiler.Profiler) {
+	profile = p
+
+	builtinNames = make(map[*object.Builtin]string, len(builtins))
+	for name, builtin := range builtins {
+		builtinNames[builtin] = name
+	}
+}
+
+// profiledFunction describes fn for the profiler. Composed functions aren't
+// profiled themselves, only the functions they're made of.
+func profiledFunction(fn object.Object) (profiler.Function, bool) {
+	switch fn := fn.(type) {
+	case *object.Function:
+		return describeFunction(fn), true
+	case *object.CurriedFunction:
+		return describeFunction(fn.Fn), true
+	case *object.Builtin:
+		name, ok := builtinNames[fn]
+		if !ok {
+			name = "builtin"
+		}
+		return profiler.Function{Name: name, Builtin: true}, true
+	default:
+		return profiler.Function{}, false
+	}
+}
+
+// describeFunction names a function by the name it's declared or let-bound
+// with, and the line it's defined in.
+func describeFunction(fn *object.Function) profiler.Function {
+	name := "anonymous"
+	if fn.Literal.Name != "" {
+		name = fn.Literal.Name
+	}
+
+	metadata := fn.Literal.Token.Metadata
+	return profiler.Function{Name: name, File: metadata.File, Line: metadata.Line}
+}
@@ -0,0 +1,87 @@
+package evaluator
+
+import (
+	"github.com/uesteibar/lainoa/pkg/ast"
+	"github.com/uesteibar/lainoa/pkg/object"
+)
+
+func evalSliceOperation(slice *ast.SliceExpression, env *object.Environment) object.Object {
+	left := Eval(slice.Left, env)
+	if object.IsError(left) {
+		return left
+	}
+	start, err := evalSliceBound(slice.Start, env)
+	if err != nil {
+		return err
+	}
+	end, err := evalSliceBound(slice.End, env)
+	if err != nil {
+		return err
+	}
+
+	switch left := left.(type) {
+	case *object.Array:
+		from, to := resolveSliceBounds(start, end, len(left.Elements))
+		elements := make([]object.Object, to-from)
+		copy(elements, left.Elements[from:to])
+
+		return &object.Array{Elements: elements}
+	case *object.String:
+		chars := []rune(left.Value)
+		from, to := resolveSliceBounds(start, end, len(chars))
+
+		return &object.String{Value: string(chars[from:to])}
+	default:
+		return object.NewError("type %s doesn't support slice operations", left.Type())
+	}
+}
+
+func evalSliceBound(bound ast.Expression, env *object.Environment) (*object.Integer, *object.Error) {
+	if bound == nil {
+		return nil, nil
+	}
+
+	evaluated := Eval(bound, env)
+	switch evaluated := evaluated.(type) {
+	case *object.Error:
+		return nil, evaluated
+	case *object.Integer:
+		return evaluated, nil
+	default:
+		return nil, object.NewError("expected %s as slice bound, got %s", object.INTEGER_OBJECT, evaluated.Type())
+	}
+}
+
+// resolveSliceBounds clamps both bounds to the sequence, so slicing never
+// fails because of out of range bounds: omitted bounds mean the start and the
+// end of the sequence, negative bounds count from the end, and a start past
+// the end produces an empty result.
+func resolveSliceBounds(start *object.Integer, end *object.Integer, length int) (int, int) {
+	from, to := 0, length
+	if start != nil {
+		from = clampBound(start.Value, length)
+	}
+	if end != nil {
+		to = clampBound(end.Value, length)
+	}
+	if from > to {
+		from = to
+	}
+
+	return from, to
+}
+
+func clampBound(bound int64, length int) int {
+	if bound < 0 {
+		bound += int64(length)
+	}
+
+	if bound < 0 {
+		return 0
+	}
+	if bound > int64(length) {
+		return length
+	}
+
+	return int(bound)
+}
@@ -0,0 +1,19 @@
+package evaluator
+
+import (
+	"github.com/uesteibar/lainoa/pkg/ast"
+	"github.com/uesteibar/lainoa/pkg/object"
+)
+
+func evalSpread(spread *ast.SpreadExpression, env *object.Environment) ([]object.Object, *object.Error) {
+	evaluated := Eval(spread.Value, env)
+
+	switch evaluated := evaluated.(type) {
+	case *object.Error:
+		return nil, evaluated
+	case *object.Array:
+		return evaluated.Elements, nil
+	default:
+		return nil, object.NewError("can't spread %s, expected %s", evaluated.Type(), object.ARRAY_OBJECT)
+	}
+}
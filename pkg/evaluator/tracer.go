@@ -0,0 +1,24 @@
+package evaluator
+
+import (
+	"github.com/uesteibar/lainoa/pkg/ast"
+	"github.com/uesteibar/lainoa/pkg/object"
+)
+
+// Tracer follows the evaluation step by step, which is what debuggers need.
+type Tracer interface {
+	// Statement is called before evaluating each statement, with the
+	// environment it's evaluated in. Returning an error stops the program.
+	Statement(stmt ast.Statement, env *object.Environment) *object.Error
+	// Call and Return wrap every call to a Lainoa function.
+	Call(fn *object.Function)
+	Return()
+}
+
+var tracer Tracer
+
+// SetTracer makes the evaluator report its progress to t. Passing nil turns
+// tracing off.
+func SetTracer(t Tracer) {
+	tracer = t
+}
@@ -9,6 +9,15 @@ func evalExpressions(exps []ast.Expression, env *object.Environment) ([]object.O
 	var result []object.Object
 
 	for _, e := range exps {
+		if spread, ok := e.(*ast.SpreadExpression); ok {
+			elements, err := evalSpread(spread, env)
+			if err != nil {
+				return result, err
+			}
+			result = append(result, elements...)
+			continue
+		}
+
 		evaluated := Eval(e, env)
 		if err, ok := evaluated.(*object.Error); ok {
 			return result, err
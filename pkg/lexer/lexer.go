@@ -1,16 +1,21 @@
 package lexer
 
 import (
+	"unicode"
+	"unicode/utf8"
+
 	"github.com/uesteibar/lainoa/pkg/token"
 )
 
+// Lexer reads the input as UTF-8, so ch is always a full character, while
+// position and readPosition are byte offsets into the input.
 type Lexer struct {
 	input        string
 	filename     string
 	position     int
 	readPosition int
 	curLine      int
-	ch           byte
+	ch           rune
 }
 
 func New(input string, filename string) *Lexer {
@@ -22,25 +27,39 @@ func New(input string, filename string) *Lexer {
 }
 
 func (l *Lexer) readChar() {
+	width := 1
 	if l.readPosition >= len(l.input) {
 		l.ch = 0
 	} else {
-		l.ch = l.input[l.readPosition]
+		l.ch, width = utf8.DecodeRuneInString(l.input[l.readPosition:])
 	}
 	l.position = l.readPosition
-	l.readPosition++
+	l.readPosition += width
 
 	if l.isLineBreak() {
 		l.curLine++
 	}
 }
 
-func (l *Lexer) peekNextChar() byte {
+func (l *Lexer) peekNextChar() rune {
 	if l.readPosition >= len(l.input) {
 		return 0
 	}
 
-	return l.input[l.readPosition]
+	ch, _ := utf8.DecodeRuneInString(l.input[l.readPosition:])
+	return ch
+}
+
+func (l *Lexer) peekNextChars(n int) string {
+	end := l.readPosition + n
+	if end > len(l.input) {
+		end = len(l.input)
+	}
+	if l.readPosition >= end {
+		return ""
+	}
+
+	return l.input[l.readPosition:end]
 }
 
 func (l *Lexer) NextToken() (t token.Token) {
@@ -74,6 +93,20 @@ func (l *Lexer) NextToken() (t token.Token) {
 	case ';':
 		t = l.newToken(token.SEMICOLON, l.ch)
 		l.readChar()
+	case ':':
+		t = l.newToken(token.COLON, l.ch)
+		l.readChar()
+	case '.':
+		if l.peekNextChars(2) == ".." {
+			l.readChar()
+			l.readChar()
+			t.Literal = "..."
+			t.Type = token.ELLIPSIS
+			t.Metadata = l.metadata()
+		} else {
+			t = l.newToken(token.ILLEGAL, l.ch)
+		}
+		l.readChar()
 	case '(':
 		t = l.newToken(token.LPAREN, l.ch)
 		l.readChar()
@@ -93,22 +126,67 @@ func (l *Lexer) NextToken() (t token.Token) {
 		t = l.newToken(token.RBRACKET, l.ch)
 		l.readChar()
 	case '+':
-		t = l.newToken(token.PLUS, l.ch)
+		if l.peekNextChar() == '=' {
+			l.readChar()
+			t.Literal = "+="
+			t.Type = token.PLUS_ASSIGN
+			t.Metadata = l.metadata()
+		} else {
+			t = l.newToken(token.PLUS, l.ch)
+		}
 		l.readChar()
 	case '-':
-		t = l.newToken(token.MINUS, l.ch)
+		if l.peekNextChar() == '=' {
+			l.readChar()
+			t.Literal = "-="
+			t.Type = token.MINUS_ASSIGN
+			t.Metadata = l.metadata()
+		} else {
+			t = l.newToken(token.MINUS, l.ch)
+		}
 		l.readChar()
 	case '*':
-		t = l.newToken(token.ASTERISK, l.ch)
+		if l.peekNextChar() == '=' {
+			l.readChar()
+			t.Literal = "*="
+			t.Type = token.ASTERISK_ASSIGN
+			t.Metadata = l.metadata()
+		} else {
+			t = l.newToken(token.ASTERISK, l.ch)
+		}
 		l.readChar()
 	case '/':
-		t = l.newToken(token.SLASH, l.ch)
+		if l.peekNextChar() == '=' {
+			l.readChar()
+			t.Literal = "/="
+			t.Type = token.SLASH_ASSIGN
+			t.Metadata = l.metadata()
+		} else {
+			t = l.newToken(token.SLASH, l.ch)
+		}
 		l.readChar()
 	case '<':
 		t = l.newToken(token.LT, l.ch)
 		l.readChar()
 	case '>':
-		t = l.newToken(token.GT, l.ch)
+		if l.peekNextChar() == '>' {
+			l.readChar()
+			t.Literal = ">>"
+			t.Type = token.COMPOSE
+			t.Metadata = l.metadata()
+		} else {
+			t = l.newToken(token.GT, l.ch)
+		}
+		l.readChar()
+	case '|':
+		if l.peekNextChar() == '>' {
+			l.readChar()
+			t.Literal = "|>"
+			t.Type = token.PIPE
+			t.Metadata = l.metadata()
+		} else {
+			t = l.newToken(token.ILLEGAL, l.ch)
+		}
 		l.readChar()
 	case '"':
 		t.Metadata = l.metadata()
@@ -144,6 +222,15 @@ func (l *Lexer) NextToken() (t token.Token) {
 	return t
 }
 
+// Offset returns the byte offset in the input right after the last token.
+func (l *Lexer) Offset() int {
+	if l.position > len(l.input) {
+		return len(l.input)
+	}
+
+	return l.position
+}
+
 func (l *Lexer) skipWhitespace() {
 	for l.ch == ' ' || l.ch == '\t' || l.isLineBreak() || l.ch == '\r' {
 		l.readChar()
@@ -157,7 +244,7 @@ func (l *Lexer) isLineBreak() bool {
 func (l *Lexer) readString() string {
 	l.readChar()
 	initialPosition := l.position
-	for l.ch != '"' {
+	for l.ch != '"' && l.ch != 0 {
 		l.readChar()
 	}
 
@@ -193,7 +280,7 @@ func (l *Lexer) readComment() string {
 	return l.input[initialPosition:l.position]
 }
 
-func (l *Lexer) newToken(tokenType token.TokenType, ch byte) token.Token {
+func (l *Lexer) newToken(tokenType token.TokenType, ch rune) token.Token {
 	return token.Token{Type: tokenType, Literal: string(ch), Metadata: l.metadata()}
 }
 
@@ -201,10 +288,11 @@ func (l *Lexer) metadata() token.Metadata {
 	return token.Metadata{Line: l.curLine, File: l.filename}
 }
 
-func isLetter(ch byte) bool {
-	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
+func isLetter(ch rune) bool {
+	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_' ||
+		ch >= utf8.RuneSelf && unicode.IsLetter(ch)
 }
 
-func isDigit(ch byte) bool {
+func isDigit(ch rune) bool {
 	return '0' <= ch && ch <= '9'
 }
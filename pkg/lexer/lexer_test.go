@@ -41,7 +41,11 @@ func TestNextToken(t *testing.T) {
 
 		let array = [1, 2]
 		let new_array = push(array, 0)
-		[1, 2]`
+		[1, 2]
+		array[1:]
+		a += 1; a -= 1; a *= 2; a /= 2
+		sum(...numbers) .
+		a |> f >> g | b`
 
 	tests := [][]struct {
 		expectedType    token.TokenType
@@ -81,6 +85,10 @@ func TestNextToken(t *testing.T) {
 		{{token.LET, "let"}, {token.IDENT, "array"}, {token.ASSIGN, "="}, {token.LBRACKET, "["}, {token.INT, "1"}, {token.COMMA, ","}, {token.INT, "2"}, {token.RBRACKET, "]"}},
 		{{token.LET, "let"}, {token.IDENT, "new_array"}, {token.ASSIGN, "="}, {token.IDENT, "push"}, {token.LPAREN, "("}, {token.IDENT, "array"}, {token.COMMA, ","}, {token.INT, "0"}, {token.RPAREN, ")"}},
 		{{token.LBRACKET, "["}, {token.INT, "1"}, {token.COMMA, ","}, {token.INT, "2"}, {token.RBRACKET, "]"}},
+		{{token.IDENT, "array"}, {token.LBRACKET, "["}, {token.INT, "1"}, {token.COLON, ":"}, {token.RBRACKET, "]"}},
+		{{token.IDENT, "a"}, {token.PLUS_ASSIGN, "+="}, {token.INT, "1"}, {token.SEMICOLON, ";"}, {token.IDENT, "a"}, {token.MINUS_ASSIGN, "-="}, {token.INT, "1"}, {token.SEMICOLON, ";"}, {token.IDENT, "a"}, {token.ASTERISK_ASSIGN, "*="}, {token.INT, "2"}, {token.SEMICOLON, ";"}, {token.IDENT, "a"}, {token.SLASH_ASSIGN, "/="}, {token.INT, "2"}},
+		{{token.IDENT, "sum"}, {token.LPAREN, "("}, {token.ELLIPSIS, "..."}, {token.IDENT, "numbers"}, {token.RPAREN, ")"}, {token.ILLEGAL, "."}},
+		{{token.IDENT, "a"}, {token.PIPE, "|>"}, {token.IDENT, "f"}, {token.COMPOSE, ">>"}, {token.IDENT, "g"}, {token.ILLEGAL, "|"}, {token.IDENT, "b"}},
 	}
 
 	l := New(input, "/path/to/file")
@@ -97,3 +105,53 @@ func TestNextToken(t *testing.T) {
 		}
 	}
 }
+
+func TestNextTokenUnicode(t *testing.T) {
+	input := `let izena = "Ñandú"
+		let año_berria = izena + "ñ€"
+		€ ✓`
+
+	tests := [][]struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{{token.LET, "let"}, {token.IDENT, "izena"}, {token.ASSIGN, "="}, {token.STRING, "Ñandú"}},
+		{{token.LET, "let"}, {token.IDENT, "año_berria"}, {token.ASSIGN, "="}, {token.IDENT, "izena"}, {token.PLUS, "+"}, {token.STRING, "ñ€"}},
+		{{token.ILLEGAL, "€"}, {token.ILLEGAL, "✓"}, {token.EOF, ""}},
+	}
+
+	l := New(input, "/path/to/file")
+
+	for i, line := range tests {
+		expectedLine := i + 1
+		for _, et := range line {
+			tok := l.NextToken()
+
+			assert.Equal(t, et.expectedType, tok.Type)
+			assert.Equal(t, et.expectedLiteral, tok.Literal)
+			assert.Equal(t, expectedLine, tok.Metadata.Line)
+		}
+	}
+}
+
+func TestUnterminatedString(t *testing.T) {
+	l := New(`"never closed`, "/path/to/file")
+
+	tok := l.NextToken()
+	assert.Equal(t, token.TokenType(token.STRING), tok.Type)
+	assert.Equal(t, "never closed", tok.Literal)
+
+	tok = l.NextToken()
+	assert.Equal(t, token.TokenType(token.EOF), tok.Type)
+}
+
+func TestOffset(t *testing.T) {
+	input := `let año = "ñ" # done`
+	expected := []int{3, 8, 10, 15, 22, 22}
+
+	l := New(input, "/path/to/file")
+	for _, offset := range expected {
+		l.NextToken()
+		assert.Equal(t, offset, l.Offset())
+	}
+}
@@ -0,0 +1,180 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/uesteibar/lainoa/pkg/ast"
+	"github.com/uesteibar/lainoa/pkg/lexer"
+	"github.com/uesteibar/lainoa/pkg/parser"
+)
+
+// Names of the rules. SYNTAX isn't a rule that can be turned off, it's used
+// for the files that can't be parsed.
+const (
+	UNUSED_BINDING       = "unused-binding"
+	UNUSED_PARAMETER     = "unused-parameter"
+	SHADOWING            = "shadowing"
+	UNREACHABLE_CODE     = "unreachable-code"
+	IF_WITHOUT_ELSE      = "if-without-else"
+	REFERENCE_COMPARISON = "reference-comparison"
+	TOO_MANY_ARGUMENTS   = "too-many-arguments"
+	SYNTAX               = "syntax"
+)
+
+const fileSuffix = ".ln"
+
+// Rule is a check the linter can make.
+type Rule struct {
+	Name        string
+	Description string
+}
+
+// Rules are every rule, all of them enabled by default.
+var Rules = []Rule{
+	{UNUSED_BINDING, "`let` bindings that are never used"},
+	{UNUSED_PARAMETER, "function parameters that are never used"},
+	{SHADOWING, "bindings with a name that's already bound, which fail when they run"},
+	{UNREACHABLE_CODE, "statements after a `return`, which never run"},
+	{IF_WITHOUT_ELSE, "`if` without `else` used as a value, which is nil when the condition is false"},
+	{REFERENCE_COMPARISON, "functions or arrays compared with == or !=, which compares their identity"},
+	{TOO_MANY_ARGUMENTS, "calls with more arguments than the function they call takes"},
+}
+
+// Diagnostic is a problem found in a line of a file.
+type Diagnostic struct {
+	Rule    string `json:"rule"`
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+// Config tells which rules are enabled, by name. Rules that aren't in it
+// are enabled.
+type Config struct {
+	Rules map[string]bool `json:"rules"`
+}
+
+// Enabled tells whether a rule should be checked.
+func (c Config) Enabled(rule string) bool {
+	enabled, ok := c.Rules[rule]
+	return !ok || enabled
+}
+
+// LoadConfig reads a JSON config file such as
+//
+//	{"rules": {"unused-parameter": false}}
+func LoadConfig(path string) (Config, error) {
+	config := Config{}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("%s: %s", path, err)
+	}
+
+	for name := range config.Rules {
+		if !isRule(name) {
+			return config, fmt.Errorf("%s: unknown rule %q", path, name)
+		}
+	}
+
+	return config, nil
+}
+
+func isRule(name string) bool {
+	for _, rule := range Rules {
+		if rule.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Lint checks a program with the enabled rules, returning what it finds
+// sorted by file and line.
+func Lint(program *ast.Program, config Config) []Diagnostic {
+	l := newLinter(config)
+	l.lint(program)
+
+	sort.SliceStable(l.diagnostics, func(i, j int) bool {
+		a, b := l.diagnostics[i], l.diagnostics[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+
+	return l.diagnostics
+}
+
+// LintFiles checks every file in paths, which can be files or directories
+// to look for *.ln files in. Files that can't be parsed get a diagnostic
+// for every syntax error instead.
+func LintFiles(paths []string, config Config) ([]Diagnostic, error) {
+	diagnostics := []Diagnostic{}
+
+	for _, path := range paths {
+		files, err := findFiles(path)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+			data, err := ioutil.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+
+			diagnostics = append(diagnostics, lintSource(string(data), file, config)...)
+		}
+	}
+
+	return diagnostics, nil
+}
+
+func lintSource(source string, file string, config Config) []Diagnostic {
+	p := parser.New(lexer.New(source, file))
+	program := p.ParseProgram()
+
+	if len(p.Errors()) > 0 {
+		diagnostics := []Diagnostic{}
+		for _, err := range p.Errors() {
+			diagnostics = append(diagnostics, Diagnostic{Rule: SYNTAX, File: err.File, Line: err.Line, Message: err.Message})
+		}
+		return diagnostics
+	}
+
+	return Lint(program, config)
+}
+
+func findFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	files := []string{}
+	err = filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && strings.HasSuffix(file, fileSuffix) {
+			files = append(files, file)
+		}
+		return nil
+	})
+
+	return files, err
+}
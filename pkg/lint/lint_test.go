@@ -0,0 +1,132 @@
+package lint
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func lint(source string, config Config) []string {
+	found := []string{}
+	for _, d := range lintSource(source, "file.ln", config) {
+		found = append(found, fmt.Sprintf("%d %s: %s", d.Line, d.Rule, d.Message))
+	}
+
+	return found
+}
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		source   string
+		expected []string
+	}{
+		{"let a = 1; puts(a)", []string{}},
+		{"let a = 1\nlet _b = 2", []string{"1 unused-binding: `a` is never used"}},
+		{"let a = 1; a = 2", []string{"1 unused-binding: `a` is never used"}},
+		{"let a = 1; a += 2", []string{}},
+		{"let test_a = fun() { nil }", []string{}},
+		{"fun f(a, b, _c, ...others) { a }; f", []string{
+			"1 unused-parameter: `b` is never used",
+			"1 unused-parameter: `others` is never used",
+		}},
+		{"fun f(a, b = a) { b }; f", []string{}},
+		{"let f = fun() { g() }; let g = fun() { 1 }; f", []string{}},
+
+		{"let a = 1; a\nlet a = 2; a", []string{"2 shadowing: `a` is already bound on line 1"}},
+		{"let a = 1\nif (true) {\n  let a = 2; a\n}; a", []string{"3 shadowing: `a` shadows the binding on line 1"}},
+		{"let a = 1; a\nfun f(a) { a }; f", []string{"2 shadowing: `a` shadows the binding on line 1"}},
+		{"let len = 1; len", []string{"1 shadowing: `len` shadows the builtin with the same name"}},
+		{"if (true) { let a = 1; a } else { let a = 2; a }", []string{}},
+
+		{"fun f() {\n  return 1\n  puts(2)\n  puts(3)\n}; f", []string{"3 unreachable-code: unreachable code after return"}},
+		{"fun f() {\n  return g()\n  fun g() { 1 }\n}; f", []string{}},
+		{"fun f(a) { if (a) { return 1 }; 2 }; f", []string{}},
+
+		{"let a = if (true) { 1 }; a", []string{"1 if-without-else: `if` without `else` used as a value is nil when the condition is false"}},
+		{"puts(if (true) { 1 })", []string{"1 if-without-else: `if` without `else` used as a value is nil when the condition is false"}},
+		{"if (true) { puts(1) }; 1", []string{}},
+		{"if (true) { puts(1) }", []string{"1 if-without-else: `if` without `else` used as a value is nil when the condition is false"}},
+		{"let f = fun(a) { if (a) { 1 } }; f", []string{"1 if-without-else: `if` without `else` used as a value is nil when the condition is false"}},
+		{"fun f(a) {\n  if (a) {\n    if (a) { 1 }\n  } else { 2 }\n}; f", []string{"3 if-without-else: `if` without `else` used as a value is nil when the condition is false"}},
+		{"fun f(a) {\n  if (a) {\n    if (a) { 1 }\n  }\n  2\n}; f", []string{}},
+		{"let a = if (true) { 1 } else { 2 }; a", []string{}},
+
+		{"let f = fun() { 1 }; f == f", []string{"1 reference-comparison: functions compared with == are only equal to themselves"}},
+		{"[1] != [1]", []string{"1 reference-comparison: arrays compared with != are only equal to themselves, not to arrays with the same elements"}},
+		{"let a = [1]; a = 1; a == 1", []string{}},
+		{`"a" == "a"; 1 == 1`, []string{}},
+
+		{"fun add(a, b) { a + b }\nadd(1, 2, 3)", []string{"2 too-many-arguments: `add` takes 2 arguments, called with 3"}},
+		{"fun add(a, b) { a + b }\n1 |> add(2, 3)", []string{"2 too-many-arguments: `add` takes 2 arguments, called with 3"}},
+		{"fun(a) { a }(1, 2)", []string{"1 too-many-arguments: function takes 1 arguments, called with 2"}},
+		{"fun add(a, b) { a + b }; add(1); 1 |> add(2)", []string{}},
+		{"fun add(a, b) { a + b }\nfun id(x) { x }\n1 |> add(2, 3) >> id", []string{"3 too-many-arguments: `add` takes 2 arguments, called with 3"}},
+		{"fun add(a, b) { a + b }; fun id(x) { x }; 1 |> add(2) >> id", []string{}},
+		{"fun f(...others) { others }; f(1, 2, 3)", []string{}},
+		{"fun add(a, b) { a + b }; let l = []; add(1, ...l)", []string{}},
+
+		{"let = 1", []string{
+			"1 syntax: expected next token to be IDENT, got = instead",
+			"1 syntax: prefix operation = not recognized",
+		}},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, lint(tt.source, Config{}), tt.source)
+	}
+}
+
+func TestConfig(t *testing.T) {
+	source := "let a = 1\nfun f(b) { nil }; f"
+	config := Config{Rules: map[string]bool{UNUSED_PARAMETER: false, SHADOWING: true}}
+
+	assert.Equal(t, []string{"1 unused-binding: `a` is never used"}, lint(source, config))
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lint")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "lint.json")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`{"rules": {"shadowing": false}}`), 0644))
+
+	config, err := LoadConfig(path)
+	assert.NoError(t, err)
+	assert.False(t, config.Enabled(SHADOWING))
+	assert.True(t, config.Enabled(UNUSED_BINDING))
+
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`{"rules": {"shadow": false}}`), 0644))
+	_, err = LoadConfig(path)
+	assert.EqualError(t, err, path+`: unknown rule "shadow"`)
+}
+
+func TestLintFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lint")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "a.ln"), []byte("let a = 1"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "b.ln"), []byte("1 == 1"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "notes.txt"), []byte("let"), 0644))
+
+	diagnostics, err := LintFiles([]string{dir}, Config{})
+	assert.NoError(t, err)
+
+	var out bytes.Buffer
+	assert.NoError(t, WriteText(&out, diagnostics))
+	assert.Equal(t, filepath.Join(dir, "a.ln")+":1: `a` is never used (unused-binding)\n", out.String())
+
+	out.Reset()
+	assert.NoError(t, WriteJSON(&out, diagnostics))
+	assert.JSONEq(t, fmt.Sprintf(`[{"rule": "unused-binding", "file": %q, "line": 1, "message": "`+"`a`"+` is never used"}]`,
+		filepath.Join(dir, "a.ln")), out.String())
+
+	_, err = LintFiles([]string{filepath.Join(dir, "missing.ln")}, Config{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,369 @@
+package lint
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/uesteibar/lainoa/pkg/ast"
+	"github.com/uesteibar/lainoa/pkg/evaluator"
+	"github.com/uesteibar/lainoa/pkg/token"
+)
+
+type kind int
+
+// What's known about a value before running the program. Only literals and
+// the bindings holding them are known.
+const (
+	UNKNOWN = kind(iota)
+	FUNCTION
+	ARRAY
+)
+
+type value struct {
+	kind     kind
+	arity    int
+	variadic bool
+}
+
+type binding struct {
+	ident *ast.Identifier
+	value value
+	used  bool
+	// rule reports the binding when it's never used, none when it's empty
+	rule string
+}
+
+// scope mirrors the environments the evaluator creates: one for the
+// program, one for every function call and one for every `if` branch.
+type scope struct {
+	outer    *scope
+	bindings map[string]*binding
+}
+
+func newScope(outer *scope) *scope {
+	return &scope{outer: outer, bindings: make(map[string]*binding)}
+}
+
+func (s *scope) lookup(name string) (*binding, *scope) {
+	for scope := s; scope != nil; scope = scope.outer {
+		if b, ok := scope.bindings[name]; ok {
+			return b, scope
+		}
+	}
+
+	return nil, nil
+}
+
+type linter struct {
+	config      Config
+	diagnostics []Diagnostic
+	bindings    []*binding
+	// function bodies only run when they're called, once the code around
+	// them has bound what they use, so they're checked last
+	pending []func()
+}
+
+func newLinter(config Config) *linter {
+	return &linter{config: config, diagnostics: []Diagnostic{}}
+}
+
+func (l *linter) lint(program *ast.Program) {
+	l.statements(program.Statements, newScope(nil))
+	for len(l.pending) > 0 {
+		next := l.pending[0]
+		l.pending = l.pending[1:]
+		next()
+	}
+
+	for _, b := range l.bindings {
+		if !b.used && b.rule != "" && !strings.HasPrefix(b.ident.Value, "_") {
+			l.report(b.rule, b.ident.Token.Metadata, "`%s` is never used", b.ident.Value)
+		}
+	}
+
+	l.ifsWithoutElse(program)
+}
+
+func (l *linter) report(rule string, position token.Metadata, format string, args ...interface{}) {
+	if !l.config.Enabled(rule) {
+		return
+	}
+
+	l.diagnostics = append(l.diagnostics, Diagnostic{
+		Rule:    rule,
+		File:    position.File,
+		Line:    position.Line,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (l *linter) statements(statements []ast.Statement, s *scope) {
+	for _, stmt := range statements {
+		if decl, ok := stmt.(*ast.FunctionDeclaration); ok {
+			l.declare(decl.Name, s, functionValue(decl.Function), "")
+		}
+	}
+
+	returned, reported := false, false
+	for _, stmt := range statements {
+		// declarations are hoisted, so they're bound even after a return
+		if _, ok := stmt.(*ast.FunctionDeclaration); returned && !reported && !ok {
+			l.report(UNREACHABLE_CODE, statementToken(stmt).Metadata, "unreachable code after return")
+			reported = true
+		}
+
+		switch stmt := stmt.(type) {
+		case *ast.LetStatement:
+			val := l.expression(stmt.Value, s)
+			rule := UNUSED_BINDING
+			if s.outer == nil && strings.HasPrefix(stmt.Name.Value, "test_") {
+				rule = ""
+			}
+			l.declare(stmt.Name, s, val, rule)
+		case *ast.ReturnStatement:
+			l.expression(stmt.Value, s)
+			returned = true
+		case *ast.ExpressionStatement:
+			l.expression(stmt.Expression, s)
+		case *ast.FunctionDeclaration:
+			l.function(stmt.Function, s)
+		}
+	}
+}
+
+func statementToken(stmt ast.Statement) token.Token {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		return stmt.Token
+	case *ast.ReturnStatement:
+		return stmt.Token
+	case *ast.ExpressionStatement:
+		return stmt.Token
+	case *ast.FunctionDeclaration:
+		return stmt.Token
+	default:
+		return token.Token{}
+	}
+}
+
+// declare binds ident in s, reporting it when the name is bound already,
+// as the evaluator refuses to bind it again.
+func (l *linter) declare(ident *ast.Identifier, s *scope, val value, rule string) {
+	if existing, in := s.lookup(ident.Value); existing != nil {
+		if in == s {
+			l.report(SHADOWING, ident.Token.Metadata, "`%s` is already bound on line %d", ident.Value, existing.ident.Token.Metadata.Line)
+		} else {
+			l.report(SHADOWING, ident.Token.Metadata, "`%s` shadows the binding on line %d", ident.Value, existing.ident.Token.Metadata.Line)
+		}
+	} else if isBuiltin(ident.Value) {
+		l.report(SHADOWING, ident.Token.Metadata, "`%s` shadows the builtin with the same name", ident.Value)
+	}
+
+	b := &binding{ident: ident, value: val, rule: rule}
+	s.bindings[ident.Value] = b
+	l.bindings = append(l.bindings, b)
+}
+
+func isBuiltin(name string) bool {
+	for _, builtin := range evaluator.Builtins() {
+		if builtin == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (l *linter) block(block *ast.BlockStatement, s *scope) {
+	if block != nil {
+		l.statements(block.Statements, newScope(s))
+	}
+}
+
+func (l *linter) expressions(expressions []ast.Expression, s *scope) {
+	for _, exp := range expressions {
+		l.expression(exp, s)
+	}
+}
+
+// expression checks exp and returns what's known about its value.
+func (l *linter) expression(exp ast.Expression, s *scope) value {
+	switch exp := exp.(type) {
+	case *ast.Identifier:
+		if b, _ := s.lookup(exp.Value); b != nil {
+			b.used = true
+			return b.value
+		}
+	case *ast.FunctionLiteral:
+		l.function(exp, s)
+		return functionValue(exp)
+	case *ast.ArrayExpression:
+		l.expressions(exp.Expressions, s)
+		return value{kind: ARRAY}
+	case *ast.IfExpression:
+		l.expression(exp.Condition, s)
+		l.block(exp.Consequence, s)
+		l.block(exp.Alternative, s)
+	case *ast.InfixExpression:
+		left, right := l.expression(exp.Left, s), l.expression(exp.Right, s)
+		if exp.Operator == token.EQ || exp.Operator == token.NOT_EQ {
+			l.comparison(exp, left, right)
+		}
+	case *ast.CallExpression:
+		fn := l.expression(exp.Function, s)
+		l.expressions(exp.Arguments, s)
+		l.arguments(exp, fn, exp.Arguments, 0)
+	case *ast.PipeExpression:
+		l.expression(exp.Left, s)
+		l.piped(exp.Right, s)
+	case *ast.AssignExpression:
+		val := l.expression(exp.Value, s)
+		if b, _ := s.lookup(exp.Name.Value); b != nil {
+			// `a += 1` reads the value it replaces
+			if exp.Token.Type != token.ASSIGN {
+				b.used = true
+			}
+			b.value = val
+		}
+	case *ast.IndexAssignExpression:
+		l.expression(exp.Target, s)
+		l.expression(exp.Value, s)
+	case *ast.PrefixExpression:
+		l.expression(exp.Right, s)
+	case *ast.IndexExpression:
+		l.expression(exp.Left, s)
+		l.expression(exp.Index, s)
+	case *ast.SliceExpression:
+		l.expression(exp.Left, s)
+		if exp.Start != nil {
+			l.expression(exp.Start, s)
+		}
+		if exp.End != nil {
+			l.expression(exp.End, s)
+		}
+	case *ast.HashLiteral:
+		l.expressions(exp.Keys, s)
+		l.expressions(exp.Values, s)
+	case *ast.SpreadExpression:
+		l.expression(exp.Value, s)
+	}
+
+	return value{kind: UNKNOWN}
+}
+
+func functionValue(fn *ast.FunctionLiteral) value {
+	return value{kind: FUNCTION, arity: len(fn.Parameters), variadic: fn.Rest != nil}
+}
+
+// function binds the parameters in a scope of their own and checks the
+// body with them, once the code around it is checked.
+func (l *linter) function(fn *ast.FunctionLiteral, outer *scope) {
+	l.pending = append(l.pending, func() {
+		s := newScope(outer)
+		for _, param := range fn.Parameters {
+			if def, ok := fn.Defaults[param.Value]; ok {
+				l.expression(def, s)
+			}
+			l.declare(param, s, value{kind: UNKNOWN}, UNUSED_PARAMETER)
+		}
+		if fn.Rest != nil {
+			l.declare(fn.Rest, s, value{kind: UNKNOWN}, UNUSED_PARAMETER)
+		}
+
+		// the body runs in the same environment as the parameters
+		l.statements(fn.Body.Statements, s)
+	})
+}
+
+func (l *linter) comparison(infix *ast.InfixExpression, left value, right value) {
+	for _, val := range []value{left, right} {
+		switch val.kind {
+		case FUNCTION:
+			l.report(REFERENCE_COMPARISON, infix.Token.Metadata, "functions compared with %s are only equal to themselves", infix.Operator)
+			return
+		case ARRAY:
+			l.report(REFERENCE_COMPARISON, infix.Token.Metadata, "arrays compared with %s are only equal to themselves, not to arrays with the same elements", infix.Operator)
+			return
+		}
+	}
+}
+
+// piped checks the right side of a pipe, where the value goes into the first
+// call, also when it's the first function of a composition.
+func (l *linter) piped(exp ast.Expression, s *scope) {
+	switch exp := exp.(type) {
+	case *ast.CallExpression:
+		fn := l.expression(exp.Function, s)
+		l.expressions(exp.Arguments, s)
+		l.arguments(exp, fn, exp.Arguments, 1)
+	case *ast.InfixExpression:
+		if exp.Operator != token.COMPOSE {
+			l.expression(exp, s)
+			return
+		}
+		l.piped(exp.Left, s)
+		l.expression(exp.Right, s)
+	default:
+		l.expression(exp, s)
+	}
+}
+
+// arguments reports calls with more arguments than the function takes.
+// piped are the arguments the call gets from a pipe.
+func (l *linter) arguments(call *ast.CallExpression, fn value, args []ast.Expression, piped int) {
+	if fn.kind != FUNCTION || fn.variadic {
+		return
+	}
+	for _, arg := range args {
+		// a spread can be empty
+		if _, ok := arg.(*ast.SpreadExpression); ok {
+			return
+		}
+	}
+
+	if count := len(args) + piped; count > fn.arity {
+		name := "function"
+		if ident, ok := call.Function.(*ast.Identifier); ok {
+			name = "`" + ident.Value + "`"
+		}
+		l.report(TOO_MANY_ARGUMENTS, call.Token.Metadata, "%s takes %d arguments, called with %d", name, fn.arity, count)
+	}
+}
+
+// ifsWithoutElse reports the `if`s without `else` whose value is used. The
+// value of a statement is only used when it's the last one of the program,
+// of a function or of an `if` branch whose value is used itself.
+func (l *linter) ifsWithoutElse(program *ast.Program) {
+	discarded := map[*ast.IfExpression]bool{}
+	discardedBlocks := map[*ast.BlockStatement]bool{}
+	discard := func(statements []ast.Statement, used bool) {
+		for i, stmt := range statements {
+			if used && i == len(statements)-1 {
+				break
+			}
+			if exp, ok := stmt.(*ast.ExpressionStatement); ok {
+				if ifexp, ok := exp.Expression.(*ast.IfExpression); ok {
+					discarded[ifexp] = true
+					discardedBlocks[ifexp.Consequence] = true
+					discardedBlocks[ifexp.Alternative] = true
+				}
+			}
+		}
+	}
+
+	// blocks are found before what's in them, so what's discarded in them
+	// is known by the time their `if`s are
+	ast.Inspect(program, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.Program:
+			discard(node.Statements, true)
+		case *ast.BlockStatement:
+			discard(node.Statements, !discardedBlocks[node])
+		case *ast.IfExpression:
+			if node.Alternative == nil && !discarded[node] {
+				l.report(IF_WITHOUT_ELSE, node.Token.Metadata, "`if` without `else` used as a value is nil when the condition is false")
+			}
+		}
+		return true
+	})
+}
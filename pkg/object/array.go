@@ -10,12 +10,22 @@ type Array struct {
 }
 
 func (a *Array) Inspect() string {
+	return a.inspect(map[Object]bool{})
+}
+
+func (a *Array) inspect(seen map[Object]bool) string {
+	if seen[a] {
+		return "[...]"
+	}
+	seen[a] = true
+	defer delete(seen, a)
+
 	var out bytes.Buffer
 	out.WriteString("[")
 
 	expressions := []string{}
 	for _, el := range a.Elements {
-		expressions = append(expressions, el.Inspect())
+		expressions = append(expressions, inspect(el, seen))
 	}
 	out.WriteString(strings.Join(expressions, ", "))
 
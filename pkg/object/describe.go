@@ -0,0 +1,33 @@
+package object
+
+import (
+	"fmt"
+
+	"github.com/uesteibar/lainoa/pkg/ast"
+)
+
+// Describe formats a value in a single line, showing functions by their
+// name and parameters instead of their whole body.
+func Describe(obj Object) string {
+	switch obj := obj.(type) {
+	case nil:
+		return "nil"
+	case *Function:
+		return describeFunction(obj)
+	case *CurriedFunction:
+		return "curried " + describeFunction(obj.Fn)
+	case *ComposedFunction:
+		return Describe(obj.First) + " >> " + Describe(obj.Second)
+	default:
+		return obj.Inspect()
+	}
+}
+
+func describeFunction(fn *Function) string {
+	name := fn.Literal.Name
+	if name != "" {
+		name = " " + name
+	}
+
+	return fmt.Sprintf("fn%s(%s)", name, ast.ParametersString(fn.Parameters, fn.Defaults, fn.Rest))
+}
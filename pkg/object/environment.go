@@ -16,6 +16,22 @@ type Environment struct {
 	outer *Environment
 }
 
+// Bindings returns the names bound in this environment, without the ones
+// in the environments enclosing it.
+func (e *Environment) Bindings() map[string]Object {
+	bindings := make(map[string]Object, len(e.store))
+	for name, val := range e.store {
+		bindings[name] = val
+	}
+
+	return bindings
+}
+
+// Outer returns the environment enclosing this one, or nil.
+func (e *Environment) Outer() *Environment {
+	return e.outer
+}
+
 func (e *Environment) Get(name string) (Object, bool) {
 	obj, ok := e.store[name]
 	if !ok && e.outer != nil {
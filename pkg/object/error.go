@@ -1,9 +1,19 @@
 package object
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/uesteibar/lainoa/pkg/token"
+)
 
 type Error struct {
 	Message string
+	// Exit is set when the error comes from the `exit` builtin, which stops
+	// the program the same way an error does, with ExitCode as its status.
+	Exit     bool
+	ExitCode int
+	// Location is where the builtin that failed was called, when known.
+	Location token.Metadata
 }
 
 func (e *Error) Inspect() string  { return fmt.Sprintf("ERROR: %s", e.Message) }
@@ -13,6 +23,10 @@ func NewError(format string, a ...interface{}) *Error {
 	return &Error{Message: fmt.Sprintf(format, a...)}
 }
 
+func NewExit(code int) *Error {
+	return &Error{Message: fmt.Sprintf("exit with status %d", code), Exit: true, ExitCode: code}
+}
+
 func IsError(obj Object) bool {
 	if obj != nil {
 		return obj.Type() == ERROR_OBJECT
@@ -2,29 +2,29 @@ package object
 
 import (
 	"bytes"
-	"strings"
 
 	"github.com/uesteibar/lainoa/pkg/ast"
 )
 
 type Function struct {
 	Parameters []*ast.Identifier
+	Defaults   map[string]ast.Expression
+	Rest       *ast.Identifier
 	Body       *ast.BlockStatement
 	Env        *Environment
+	Literal    *ast.FunctionLiteral // where the function was defined
 }
 
 func (f *Function) Type() ObjectType { return FUNCTION_OBJECT }
 func (f *Function) Inspect() string {
 	var out bytes.Buffer
 
-	params := []string{}
-	for _, p := range f.Parameters {
-		params = append(params, p.String())
-	}
-
 	out.WriteString("fn")
+	if f.Literal.Name != "" {
+		out.WriteString(" " + f.Literal.Name)
+	}
 	out.WriteString("(")
-	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(ast.ParametersString(f.Parameters, f.Defaults, f.Rest))
 	out.WriteString(") {\n")
 	out.WriteString(f.Body.String())
 	out.WriteString("\n}")
@@ -32,6 +32,19 @@ func (f *Function) Inspect() string {
 	return out.String()
 }
 
+// RequiredParameters counts how many of the given parameters have no default
+// value. Those are the only ones taken into account when currying.
+func (f *Function) RequiredParameters(params []*ast.Identifier) int {
+	required := 0
+	for _, p := range params {
+		if _, ok := f.Defaults[p.Value]; !ok {
+			required++
+		}
+	}
+
+	return required
+}
+
 type CurriedFunction struct {
 	Fn             *Function
 	Env            *Environment
@@ -47,3 +60,22 @@ func (f *CurriedFunction) Inspect() string {
 
 	return out.String()
 }
+
+// ComposedFunction is the result of `first >> second`: calling it calls first
+// with all the arguments, and then second with the result.
+type ComposedFunction struct {
+	First  Object
+	Second Object
+}
+
+func (f *ComposedFunction) Type() ObjectType { return COMPOSED_FUNCTION_OBJECT }
+func (f *ComposedFunction) Inspect() string {
+	var out bytes.Buffer
+
+	out.WriteString("Composed Function: ")
+	out.WriteString(f.First.Inspect())
+	out.WriteString(" >> ")
+	out.WriteString(f.Second.Inspect())
+
+	return out.String()
+}
@@ -1,10 +1,61 @@
 package object
 
-import "fmt"
+import (
+	"fmt"
+	"math"
+	"math/big"
+)
 
+// Integer is an integer of any size. Most fit in Value; the ones that
+// don't are kept in Big, and Value is then the int64 closest to them, so
+// indexes and slice bounds see them as out of range. Code using integers as
+// sizes or counts has to check Big, as the closest int64 is still too big.
 type Integer struct {
 	Value int64
+	Big   *big.Int
 }
 
-func (i *Integer) Inspect() string  { return fmt.Sprintf("%d", i.Value) }
+// NewBigInteger returns the Integer for value, which only keeps it in Big
+// when it doesn't fit in an int64.
+func NewBigInteger(value *big.Int) *Integer {
+	if value.IsInt64() {
+		return &Integer{Value: value.Int64()}
+	}
+	if value.Sign() > 0 {
+		return &Integer{Value: math.MaxInt64, Big: value}
+	}
+	return &Integer{Value: math.MinInt64, Big: value}
+}
+
+// BigInt returns the value as a big.Int, which must not be modified.
+func (i *Integer) BigInt() *big.Int {
+	if i.Big != nil {
+		return i.Big
+	}
+	return big.NewInt(i.Value)
+}
+
+// Cmp compares the integer with other, returning -1, 0 or +1 when it's
+// smaller, equal or larger.
+func (i *Integer) Cmp(other *Integer) int {
+	if i.Big == nil && other.Big == nil {
+		switch {
+		case i.Value < other.Value:
+			return -1
+		case i.Value > other.Value:
+			return 1
+		default:
+			return 0
+		}
+	}
+
+	return i.BigInt().Cmp(other.BigInt())
+}
+
+func (i *Integer) Inspect() string {
+	if i.Big != nil {
+		return i.Big.String()
+	}
+	return fmt.Sprintf("%d", i.Value)
+}
 func (i *Integer) Type() ObjectType { return INTEGER_OBJECT }
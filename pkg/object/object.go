@@ -4,19 +4,35 @@ type ObjectType string
 
 // Object types
 var (
-	INTEGER_OBJECT          = ObjectType("INTEGER")
-	STRING_OBJECT           = ObjectType("STRING")
-	BOOLEAN_OBJECT          = ObjectType("BOOLEAN")
-	NIL_OBJECT              = ObjectType("NIL")
-	RETURN_VALUE_OBJECT     = ObjectType("RETURN_VALUE")
-	ERROR_OBJECT            = ObjectType("ERROR")
-	FUNCTION_OBJECT         = ObjectType("FUNCTION")
-	CURRIED_FUNCTION_OBJECT = ObjectType("CURRIED_FUNCTION")
-	BUILTIN_OBJECT          = ObjectType("BUILTIN")
-	ARRAY_OBJECT            = ObjectType("ARRAY")
+	INTEGER_OBJECT           = ObjectType("INTEGER")
+	STRING_OBJECT            = ObjectType("STRING")
+	BOOLEAN_OBJECT           = ObjectType("BOOLEAN")
+	NIL_OBJECT               = ObjectType("NIL")
+	RETURN_VALUE_OBJECT      = ObjectType("RETURN_VALUE")
+	ERROR_OBJECT             = ObjectType("ERROR")
+	FUNCTION_OBJECT          = ObjectType("FUNCTION")
+	CURRIED_FUNCTION_OBJECT  = ObjectType("CURRIED_FUNCTION")
+	COMPOSED_FUNCTION_OBJECT = ObjectType("COMPOSED_FUNCTION")
+	BUILTIN_OBJECT           = ObjectType("BUILTIN")
+	ARRAY_OBJECT             = ObjectType("ARRAY")
+	HASH_OBJECT              = ObjectType("HASH")
 )
 
 type Object interface {
 	Type() ObjectType
 	Inspect() string
 }
+
+// inspect prints an element of an array or hash. Arrays and hashes in seen
+// are being printed already, so they contain themselves, and are printed as
+// [...] or {...} instead of forever.
+func inspect(obj Object, seen map[Object]bool) string {
+	switch obj := obj.(type) {
+	case *Array:
+		return obj.inspect(seen)
+	case *Hash:
+		return obj.inspect(seen)
+	default:
+		return obj.Inspect()
+	}
+}
@@ -0,0 +1,197 @@
+package optimizer
+
+import (
+	"github.com/uesteibar/lainoa/pkg/ast"
+	"github.com/uesteibar/lainoa/pkg/evaluator"
+	"github.com/uesteibar/lainoa/pkg/object"
+	"github.com/uesteibar/lainoa/pkg/token"
+)
+
+// Optimize rewrites the program so it does less work when it runs, and
+// returns it. It folds operations on literals into their result, keeps
+// only the branch that runs of `if`s with a literal condition and removes
+// literals whose value isn't used. Operations that fail, like `1 + "a"`,
+// are left alone, so they still fail when the program runs.
+func Optimize(program *ast.Program) *ast.Program {
+	return ast.Modify(program, optimize).(*ast.Program)
+}
+
+func optimize(node ast.Node) ast.Node {
+	switch node := node.(type) {
+	case *ast.PrefixExpression:
+		if right, ok := constant(node.Right); ok {
+			return fold(node, node.Token, evaluator.PrefixOperation(node.Operator, right))
+		}
+	case *ast.InfixExpression:
+		left, leftOk := constant(node.Left)
+		right, rightOk := constant(node.Right)
+		if leftOk && rightOk {
+			return fold(node, node.Token, evaluator.InfixOperation(left, node.Operator, right))
+		}
+	case *ast.IfExpression:
+		return pruneIf(node)
+	case *ast.Program:
+		node.Statements = optimizeStatements(node.Statements)
+	case *ast.BlockStatement:
+		node.Statements = optimizeStatements(node.Statements)
+	}
+
+	return node
+}
+
+// constant returns the value of exp when it's a literal that can be folded.
+func constant(exp ast.Expression) (object.Object, bool) {
+	switch exp := exp.(type) {
+	case *ast.IntegerLiteral:
+		return &object.Integer{Value: exp.Value, Big: exp.Big}, true
+	case *ast.StringLiteral:
+		return &object.String{Value: exp.Value}, true
+	case *ast.Boolean:
+		if exp.Value {
+			return evaluator.TRUE, true
+		}
+		return evaluator.FALSE, true
+	default:
+		return nil, false
+	}
+}
+
+// fold replaces an operation on literals by its result, computed the same
+// way the evaluator does when the program runs, unless it's an error.
+func fold(exp ast.Expression, t token.Token, res object.Object) ast.Expression {
+	switch res := res.(type) {
+	case *object.Integer:
+		return &ast.IntegerLiteral{
+			Token: token.Token{Type: token.INT, Literal: res.Inspect(), Metadata: t.Metadata},
+			Value: res.Value,
+			Big:   res.Big,
+		}
+	case *object.String:
+		return &ast.StringLiteral{
+			Token: token.Token{Type: token.STRING, Literal: res.Value, Metadata: t.Metadata},
+			Value: res.Value,
+		}
+	case *object.Boolean:
+		if res.Value {
+			return &ast.Boolean{Token: token.Token{Type: token.TRUE, Literal: "true", Metadata: t.Metadata}, Value: true}
+		}
+		return &ast.Boolean{Token: token.Token{Type: token.FALSE, Literal: "false", Metadata: t.Metadata}, Value: false}
+	default:
+		return exp
+	}
+}
+
+// branch returns the block that runs for an `if` with a literal condition,
+// which is nil when it's false and there's no else.
+func branch(ifexp *ast.IfExpression) (block *ast.BlockStatement, ok bool) {
+	switch condition := ifexp.Condition.(type) {
+	case *ast.Boolean:
+		if condition.Value {
+			return ifexp.Consequence, true
+		}
+		return ifexp.Alternative, true
+	case *ast.IntegerLiteral, *ast.StringLiteral:
+		// every value but false and nil is truthy
+		return ifexp.Consequence, true
+	default:
+		return nil, false
+	}
+}
+
+// pruneIf replaces an `if` with a literal condition by the expression in
+// the branch that runs, when that's all the branch has.
+func pruneIf(ifexp *ast.IfExpression) ast.Expression {
+	block, ok := branch(ifexp)
+	if !ok || block == nil || len(block.Statements) != 1 || !canMove(block) {
+		return ifexp
+	}
+
+	if stmt, ok := block.Statements[0].(*ast.ExpressionStatement); ok {
+		return stmt.Expression
+	}
+	return ifexp
+}
+
+// optimizeStatements inlines the branch that runs of the `if` statements
+// with a literal condition, and removes the statements that have no effect.
+// The last statement is always kept, as it's the value of the block.
+func optimizeStatements(statements []ast.Statement) []ast.Statement {
+	inlined := []ast.Statement{}
+	for i, stmt := range statements {
+		last := i == len(statements)-1
+		if exp, ok := stmt.(*ast.ExpressionStatement); ok {
+			if ifexp, ok := exp.Expression.(*ast.IfExpression); ok {
+				if block, ok := branch(ifexp); ok && canInline(block, last) {
+					if block != nil {
+						inlined = append(inlined, block.Statements...)
+					}
+					continue
+				}
+			}
+		}
+
+		inlined = append(inlined, stmt)
+	}
+
+	optimized := []ast.Statement{}
+	for i, stmt := range inlined {
+		if i < len(inlined)-1 && hasNoEffect(stmt) {
+			continue
+		}
+
+		optimized = append(optimized, stmt)
+	}
+
+	return optimized
+}
+
+// canInline tells whether the statements of a branch can run in the block
+// around the `if`. A missing or empty branch as the last statement would
+// change the value of the block.
+func canInline(block *ast.BlockStatement, last bool) bool {
+	if block == nil || len(block.Statements) == 0 {
+		return !last
+	}
+
+	return canMove(block)
+}
+
+// canMove tells whether what's in a branch can run in the environment
+// around the `if` instead of in one of its own. It can't when it binds
+// names, assigns them or creates functions, which keep the environment
+// they're created in, as those depend on the environment they run in.
+func canMove(block *ast.BlockStatement) bool {
+	movable := true
+	ast.Inspect(block, func(node ast.Node) bool {
+		switch node.(type) {
+		case *ast.LetStatement, *ast.FunctionDeclaration, *ast.FunctionLiteral,
+			*ast.AssignExpression, *ast.IndexAssignExpression:
+			movable = false
+		}
+		return movable
+	})
+
+	return movable
+}
+
+func hasNoEffect(stmt ast.Statement) bool {
+	exp, ok := stmt.(*ast.ExpressionStatement)
+	return ok && isPure(exp.Expression)
+}
+
+// isPure tells whether evaluating exp can't fail or change anything.
+func isPure(exp ast.Expression) bool {
+	switch exp := exp.(type) {
+	case *ast.IntegerLiteral, *ast.StringLiteral, *ast.Boolean, *ast.NilLiteral, *ast.FunctionLiteral:
+		return true
+	case *ast.ArrayExpression:
+		for _, el := range exp.Expressions {
+			if !isPure(el) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,122 @@
+package optimizer
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uesteibar/lainoa/pkg/ast"
+	"github.com/uesteibar/lainoa/pkg/evaluator"
+	"github.com/uesteibar/lainoa/pkg/lexer"
+	"github.com/uesteibar/lainoa/pkg/object"
+	"github.com/uesteibar/lainoa/pkg/parser"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	p := parser.New(lexer.New(input, "file.ln"))
+	program := p.ParseProgram()
+	assert.Empty(t, p.Errors(), input)
+
+	return program
+}
+
+func TestOptimize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"60 * 60 * 24", "86400"},
+		{"1 + 2 * 3 - -4 / 2", "9"},
+		{"let a = 2 * a", "let a = (2 * a);"},
+		{"9223372036854775807 + 1", "9223372036854775808"},
+		{`"a" + "b" + "c"`, `"abc"`},
+		{`"a" == "a"`, "true"},
+		{"!(1 < 2) == false", "true"},
+		{"!!5", "true"},
+		{`1 + "a"`, `(1 + "a")`},
+		{"-true", "(-true)"},
+		{"1 / 0", "(1 / 0)"},
+		{"let a = if (1 < 2) { 1 } else { 2 }", "let a = 1;"},
+		{"let a = if (false) { 1 }", "let a = if false 1;"},
+		{"if (true) { puts(1); puts(2) }; 3", "puts(1)puts(2)3"},
+		{"if (false) { puts(1) }; 3", "3"},
+		{"if (false) { puts(1) }", "if false puts(1)"},
+		{"if (0) { puts(1) } else { puts(2) }; 3", "puts(1)3"},
+		{"if (true) { let a = 1; puts(a) }; 3", "if true let a = 1;puts(a)3"},
+		{"if (x) { 1 } else { 2 }", "if x 1 else 2"},
+		{"if (true) { a = 2 }; 3", "if true a = 2;3"},
+		{"if (true) { a[0] = 2 }", "if true a[0] = 2;"},
+		{"if (true) { fun() { a = 2 } }", "if true fun() a = 2;"},
+		{"1; \"a\"; [1, fun() { 2 }]; nil; puts(1); 2", "puts(1)2"},
+		{"x; 2", "x2"},
+		{"fun f() { 1 + 1; return 2 * 3 }", "fun f() return 6;"},
+	}
+
+	for _, tt := range tests {
+		optimized := Optimize(parse(t, tt.input))
+		assert.Equal(t, tt.expected, optimized.String(), tt.input)
+	}
+}
+
+type result struct {
+	value  string
+	output string
+}
+
+func run(program *ast.Program) result {
+	var out bytes.Buffer
+	evaluator.SetOutput(&out)
+	defer evaluator.SetOutput(os.Stdout)
+
+	value := "<none>"
+	if res := evaluator.Eval(program, object.NewEnvironment()); res != nil {
+		value = res.Inspect()
+	}
+
+	return result{value: value, output: out.String()}
+}
+
+// TestSameBehavior runs programs before and after optimizing them, which
+// must print and evaluate to the same, errors included.
+func TestSameBehavior(t *testing.T) {
+	programs := []string{
+		"60 * 60 * 24",
+		"let day = 60 * 60 * 24; puts(day); day / 24",
+		`let greeting = "hello" + " " + "world"; puts(greeting)`,
+		"if (1 > 2) { puts(\"no\") } else { puts(\"yes\") }",
+		"if (true) { puts(1) }; if (false) { puts(2) }; if (\"s\") { puts(3) }",
+		"if (false) { puts(1) }",
+		"if (true) { }",
+		"let a = 1; if (true) { a = a + 1 }; a",
+		"let a = 1; fun f() { if (true) { a = 2 }; nil }; f(); a",
+		"let a = 1; fun f() { if (true) { a += 2 } }; f(); a",
+		"let a = [1]; fun f() { if (true) { a[0] = 2 }; nil }; f(); a",
+		"let a = 1; fun f() { if (true) { fun() { a = 2 } } }; f()(); a",
+		"let a = 1; if (true) { let a = 2 }; a",
+		"if (true) { let b = 2 }; b",
+		"fun f(x) { if (true) { return x * (2 + 3) }; 0 }; f(2)",
+		"fun f() { if (false) { 1 } }; f()",
+		"let a = if (false) { 1 }; a",
+		"[if (true) { 1 }, if (false) { 2 } else { 3 }]",
+		"1 + true",
+		`"a" - "b"`,
+		"-\"a\"",
+		"!nil",
+		"let f = fun() { 1 }; f == f",
+		"9223372036854775807 + 1",
+		"puts(1); 1 / 0",
+		"99999999999999999999 / (1 - 1)",
+		"1; 2; missing; 3",
+		"puts(1); 1 == 1",
+		"let a = [1, 2, 3]; a[1 + 1] + a[0]",
+		`{"a" + "b": 1 + 1}["ab"]`,
+	}
+
+	for _, program := range programs {
+		expected := run(parse(t, program))
+		optimized := run(Optimize(parse(t, program)))
+
+		assert.Equal(t, expected, optimized, program)
+	}
+}
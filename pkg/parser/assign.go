@@ -2,6 +2,7 @@ package parser
 
 import (
 	"github.com/uesteibar/lainoa/pkg/ast"
+	"github.com/uesteibar/lainoa/pkg/token"
 )
 
 func (p *Parser) parseAssignExpression(ident *ast.Identifier) ast.Expression {
@@ -16,3 +17,25 @@ func (p *Parser) parseAssignExpression(ident *ast.Identifier) ast.Expression {
 
 	return a
 }
+
+func (p *Parser) parseIndexAssignExpression(target *ast.IndexExpression) ast.Expression {
+	a := &ast.IndexAssignExpression{
+		Token:  p.curToken,
+		Target: target,
+	}
+
+	// advance to the expression on the right
+	p.nextToken()
+	a.Value = p.parseExpression(LOWEST)
+
+	return a
+}
+
+func (p *Parser) peekTokenIsAssignment() bool {
+	switch p.peekToken.Type {
+	case token.ASSIGN, token.PLUS_ASSIGN, token.MINUS_ASSIGN, token.ASTERISK_ASSIGN, token.SLASH_ASSIGN:
+		return true
+	default:
+		return false
+	}
+}
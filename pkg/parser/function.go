@@ -9,25 +9,63 @@ import (
 
 func (p *Parser) parseFunctionLiteral() ast.Expression {
 	fun := &ast.FunctionLiteral{
-		Token: p.curToken,
+		Token:    p.curToken,
+		Defaults: map[string]ast.Expression{},
 	}
 
-	if !p.expectPeek(token.LPAREN) {
+	if !p.parseFunctionDefinition(fun) {
 		return nil
 	}
 
-	fun.Parameters = p.parseParameters()
+	return fun
+}
 
-	if !p.expectPeek(token.LBRACE) {
+func (p *Parser) parseFunctionDeclaration() *ast.FunctionDeclaration {
+	decl := &ast.FunctionDeclaration{Token: p.curToken}
+
+	if !p.expectPeek(token.IDENT) {
 		return nil
 	}
 
+	decl.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	decl.Function = &ast.FunctionLiteral{
+		Token:    decl.Token,
+		Defaults: map[string]ast.Expression{},
+		Name:     decl.Name.Value,
+	}
+
+	if !p.parseFunctionDefinition(decl.Function) {
+		return nil
+	}
+
+	for p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return decl
+}
+
+// parseFunctionDefinition parses the parameters and body of a function,
+// expecting the next token to be the '(' opening the parameters.
+func (p *Parser) parseFunctionDefinition(fun *ast.FunctionLiteral) bool {
+	if !p.expectPeek(token.LPAREN) {
+		return false
+	}
+
+	fun.Parameters = p.parseParameters(fun)
+
+	if !p.expectPeek(token.LBRACE) {
+		return false
+	}
+
 	fun.Body = p.parseBlockStatement()
 
-	return fun
+	return true
 }
 
-func (p *Parser) parseParameters() []*ast.Identifier {
+// parseParameters returns the positional parameters, registering default
+// values and the rest parameter in the function literal as it finds them.
+func (p *Parser) parseParameters(fun *ast.FunctionLiteral) []*ast.Identifier {
 	params := []*ast.Identifier{}
 
 	if p.peekTokenIs(token.RPAREN) { // this means there's no params
@@ -36,21 +74,14 @@ func (p *Parser) parseParameters() []*ast.Identifier {
 	}
 
 	p.nextToken()
-
-	if !p.curTokenIs(token.IDENT) {
-		p.addUnexpectedArgumentError()
-	}
-
-	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-	params = append(params, ident)
+	params = p.parseParameter(fun, params)
 
 	for p.peekTokenIs(token.COMMA) {
 		// skip comma, in "a, b" b is 2 tokens away from a
 		p.nextToken()
 		p.nextToken()
 
-		ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-		params = append(params, ident)
+		params = p.parseParameter(fun, params)
 	}
 
 	if !p.expectPeek(token.RPAREN) {
@@ -60,6 +91,42 @@ func (p *Parser) parseParameters() []*ast.Identifier {
 	return params
 }
 
+func (p *Parser) parseParameter(fun *ast.FunctionLiteral, params []*ast.Identifier) []*ast.Identifier {
+	if fun.Rest != nil {
+		p.addError(fmt.Sprintf("rest parameter `%s` must be the last parameter", fun.Rest.Value))
+	}
+
+	if p.curTokenIs(token.ELLIPSIS) {
+		p.nextToken()
+		if !p.curTokenIs(token.IDENT) {
+			p.addUnexpectedArgumentError()
+		}
+
+		fun.Rest = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		return params
+	}
+
+	if !p.curTokenIs(token.IDENT) {
+		p.addUnexpectedArgumentError()
+	}
+
+	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if p.peekTokenIs(token.ASSIGN) {
+		p.nextToken()
+		p.nextToken()
+
+		fun.Defaults[ident.Value] = p.parseExpression(LOWEST)
+	} else if len(fun.Defaults) > 0 {
+		p.addError(fmt.Sprintf(
+			"required parameter `%s` can't follow parameters with default values",
+			ident.Value,
+		))
+	}
+
+	return append(params, ident)
+}
+
 func (p *Parser) addUnexpectedArgumentError() {
 	p.addError(fmt.Sprintf(
 		"Function parameters can only be identifiers, found '%s' instead",
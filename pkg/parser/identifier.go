@@ -2,13 +2,12 @@ package parser
 
 import (
 	"github.com/uesteibar/lainoa/pkg/ast"
-	"github.com/uesteibar/lainoa/pkg/token"
 )
 
 func (p *Parser) parseIdentifier() ast.Expression {
 	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
-	if p.peekTokenIs(token.ASSIGN) {
+	if p.peekTokenIsAssignment() {
 		p.nextToken()
 		return p.parseAssignExpression(ident)
 	}
@@ -6,18 +6,51 @@ import (
 )
 
 func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
-	index := &ast.IndexExpression{
-		Token: p.curToken,
-		Left:  left,
-	}
+	tok := p.curToken
 
 	p.nextToken()
 
-	index.Index = p.parseExpression(LOWEST)
+	if p.curTokenIs(token.COLON) { // no start, as in array[:2]
+		return p.parseSliceExpression(tok, left, nil)
+	}
+
+	index := p.parseExpression(LOWEST)
+
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+		return p.parseSliceExpression(tok, left, index)
+	}
+
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
+
+	exp := &ast.IndexExpression{Token: tok, Left: left, Index: index}
+
+	if p.peekTokenIsAssignment() {
+		p.nextToken()
+		return p.parseIndexAssignExpression(exp)
+	}
+
+	return exp
+}
+
+// parseSliceExpression expects curToken to be the ':' separating both bounds
+func (p *Parser) parseSliceExpression(tok token.Token, left ast.Expression, start ast.Expression) ast.Expression {
+	slice := &ast.SliceExpression{
+		Token: tok,
+		Left:  left,
+		Start: start,
+	}
+
+	if !p.peekTokenIs(token.RBRACKET) {
+		p.nextToken()
+		slice.End = p.parseExpression(LOWEST)
+	}
 
 	if !p.expectPeek(token.RBRACKET) {
 		return nil
 	}
 
-	return index
+	return slice
 }
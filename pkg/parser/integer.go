@@ -2,15 +2,25 @@ package parser
 
 import (
 	"fmt"
+	"math"
+	"math/big"
 	"strconv"
 
 	"github.com/uesteibar/lainoa/pkg/ast"
 )
 
+// parseInteger keeps the integers that don't fit in an int64 in Big, with
+// Value set to the largest int64 as the evaluator expects.
 func (p *Parser) parseInteger() ast.Expression {
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
-	if err != nil {
-		p.addError(fmt.Sprintf("Couldn't parse %q as integer", p.curToken.Literal))
+	if err == nil {
+		return &ast.IntegerLiteral{Token: p.curToken, Value: value}
 	}
+
+	if n, ok := new(big.Int).SetString(p.curToken.Literal, 0); ok {
+		return &ast.IntegerLiteral{Token: p.curToken, Value: math.MaxInt64, Big: n}
+	}
+
+	p.addError(fmt.Sprintf("Couldn't parse %q as integer", p.curToken.Literal))
 	return &ast.IntegerLiteral{Token: p.curToken, Value: value}
 }
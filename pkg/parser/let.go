@@ -19,6 +19,9 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 	}
 	p.nextToken()
 	stmt.Value = p.parseExpression(LOWEST)
+	if fun, ok := stmt.Value.(*ast.FunctionLiteral); ok {
+		fun.Name = stmt.Name.Value
+	}
 
 	for p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
@@ -13,6 +13,8 @@ import (
 const (
 	_ int = iota
 	LOWEST
+	PIPE        // |>
+	COMPOSE     // >>
 	EQUALS      // ==
 	LESSGREATER // > or <
 	SUM         // +
@@ -23,6 +25,8 @@ const (
 )
 
 var precedences = map[token.TokenType]int{
+	token.PIPE:     PIPE,
+	token.COMPOSE:  COMPOSE,
 	token.EQ:       EQUALS,
 	token.NOT_EQ:   EQUALS,
 	token.LT:       LESSGREATER,
@@ -86,6 +90,8 @@ func New(l *lexer.Lexer) *Parser {
 
 	p.registerPrefix(token.LBRACKET, p.parseArray)
 
+	p.registerPrefix(token.LBRACE, p.parseHashLiteral)
+
 	p.registerPrefix(token.TRUE, p.parseBoolean)
 	p.registerPrefix(token.FALSE, p.parseBoolean)
 
@@ -99,6 +105,8 @@ func New(l *lexer.Lexer) *Parser {
 
 	p.registerPrefix(token.FUNCTION, p.parseFunctionLiteral)
 
+	p.registerPrefix(token.ELLIPSIS, p.parseSpreadExpression)
+
 	p.infixParseFns = make(map[token.TokenType]infixParseFn)
 	p.registerInfix(token.PLUS, p.parseInfixExpression)
 	p.registerInfix(token.MINUS, p.parseInfixExpression)
@@ -108,6 +116,9 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.NOT_EQ, p.parseInfixExpression)
 	p.registerInfix(token.LT, p.parseInfixExpression)
 	p.registerInfix(token.GT, p.parseInfixExpression)
+	p.registerInfix(token.COMPOSE, p.parseInfixExpression)
+
+	p.registerInfix(token.PIPE, p.parsePipeExpression)
 
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
 
@@ -149,6 +160,11 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseLetStatement()
 	case token.RETURN:
 		return p.parseReturnStatement()
+	case token.FUNCTION:
+		if p.peekTokenIs(token.IDENT) {
+			return p.parseFunctionDeclaration()
+		}
+		return p.parseExpressionStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"math"
 	"strconv"
 	"testing"
 
@@ -172,6 +173,25 @@ func TestIntegerExpression(t *testing.T) {
 	assertLiteralExpression(t, stmt.Expression, 550)
 }
 
+func TestBigIntegerExpression(t *testing.T) {
+	l := lex(`123456789012345678901234567890`)
+
+	p := New(l)
+	program := p.ParseProgram()
+
+	assertNoErrors(t, p)
+	assert.Len(t, program.Statements, 1)
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	assert.True(t, ok)
+
+	integer, ok := stmt.Expression.(*ast.IntegerLiteral)
+	assert.True(t, ok)
+	assert.Equal(t, "123456789012345678901234567890", integer.Big.String())
+	assert.Equal(t, int64(math.MaxInt64), integer.Value)
+	assert.Equal(t, "123456789012345678901234567890", integer.String())
+}
+
 func TestStringExpression(t *testing.T) {
 	l := lex(`"unai"`)
 
@@ -358,6 +378,22 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"add(a + b + c * d / f + g)",
 			"add((((a + b) + ((c * d) / f)) + g))",
 		},
+		{
+			"a + 1 |> f |> g(b)",
+			"(((a + 1) |> f) |> g(b))",
+		},
+		{
+			"a |> f >> g",
+			"(a |> (f >> g))",
+		},
+		{
+			"f >> g >> h",
+			"((f >> g) >> h)",
+		},
+		{
+			"a |> f == b",
+			"(a |> (f == b))",
+		},
 	}
 
 	for _, tt := range tests {
@@ -585,6 +621,133 @@ func TestFunctionParameterParsing(t *testing.T) {
 	}
 }
 
+func TestFunctionDefaultAndRestParameters(t *testing.T) {
+	l := lex(`fun(a, b = 2, c = a + 1, ...rest) { a }`)
+	p := New(l)
+	program := p.ParseProgram()
+	assertNoErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	fun := stmt.Expression.(*ast.FunctionLiteral)
+
+	assert.Len(t, fun.Parameters, 3)
+	assertIdentifier(t, fun.Parameters[0], "a")
+	assertIdentifier(t, fun.Parameters[1], "b")
+	assertIdentifier(t, fun.Parameters[2], "c")
+
+	assert.Len(t, fun.Defaults, 2)
+	assertIntegerLiteral(t, fun.Defaults["b"], 2)
+	assertInfixExpression(t, fun.Defaults["c"], "a", "+", 1)
+
+	assertIdentifier(t, fun.Rest, "rest")
+
+	assert.Equal(t, "fun(a, b = 2, c = (a + 1), ...rest) a", fun.String())
+}
+
+func TestFunctionDefaultAndRestParametersErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"fun(a = 1, b) {}",
+			"/path/to/file:1 required parameter `b` can't follow parameters with default values",
+		},
+		{
+			"fun(...rest, b) {}",
+			"/path/to/file:1 rest parameter `rest` must be the last parameter",
+		},
+		{
+			"fun(...1) {}",
+			"/path/to/file:1 Function parameters can only be identifiers, found '1' instead",
+		},
+	}
+
+	for _, tt := range tests {
+		l := lex(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		assert.Len(t, errors, 1)
+		assert.Equal(t, tt.expected, errors[0].String())
+	}
+}
+
+func TestSpreadExpressionParsing(t *testing.T) {
+	l := lex("add(1, ...numbers, ...rest(list))")
+	p := New(l)
+	program := p.ParseProgram()
+	assertNoErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	call, ok := stmt.Expression.(*ast.CallExpression)
+	assert.True(t, ok)
+
+	assert.Len(t, call.Arguments, 3)
+	spread, ok := call.Arguments[1].(*ast.SpreadExpression)
+	assert.True(t, ok)
+	assertIdentifier(t, spread.Value, "numbers")
+
+	assert.Equal(t, "add(1, ...numbers, ...rest(list))", program.String())
+}
+
+func TestFunctionDeclaration(t *testing.T) {
+	l := lex(`
+		fun add(a, b = 1) {
+			a + b;
+		};
+		fun() { 1 }
+	`)
+	p := New(l)
+	program := p.ParseProgram()
+	assertNoErrors(t, p)
+
+	assert.Len(t, program.Statements, 2)
+
+	decl, ok := program.Statements[0].(*ast.FunctionDeclaration)
+	assert.True(t, ok)
+
+	assertIdentifier(t, decl.Name, "add")
+	assert.Len(t, decl.Function.Parameters, 2)
+	assertIdentifier(t, decl.Function.Parameters[0], "a")
+	assertIdentifier(t, decl.Function.Parameters[1], "b")
+	assert.Equal(t, "fun add(a, b = 1) (a + b)", decl.String())
+	assert.Equal(t, "add", decl.Function.Name)
+
+	exp, ok := program.Statements[1].(*ast.ExpressionStatement)
+	assert.True(t, ok)
+	fun, ok := exp.Expression.(*ast.FunctionLiteral)
+	assert.True(t, ok)
+	assert.Equal(t, "", fun.Name)
+}
+
+func TestLetBoundFunctionName(t *testing.T) {
+	l := lex(`let double = fun(x) { x * 2 }`)
+	p := New(l)
+	program := p.ParseProgram()
+	assertNoErrors(t, p)
+
+	let, ok := program.Statements[0].(*ast.LetStatement)
+	assert.True(t, ok)
+	fun, ok := let.Value.(*ast.FunctionLiteral)
+	assert.True(t, ok)
+	assert.Equal(t, "double", fun.Name)
+}
+
+func TestFunctionDeclarationErrors(t *testing.T) {
+	l := lex(`fun add { a + b }`)
+	p := New(l)
+	p.ParseProgram()
+
+	errors := p.Errors()
+	assert.NotEmpty(t, errors)
+	assert.Equal(t,
+		"/path/to/file:1 expected next token to be (, got { instead",
+		errors[0].String(),
+	)
+}
+
 func TestCallExpressionParsing(t *testing.T) {
 	input := "add(1, 2 * 3, 4 + 5);"
 
@@ -667,6 +830,66 @@ func TestAssignExpressionParsing(t *testing.T) {
 	assertIdentifier(t, exp.Name, "a")
 }
 
+func TestCompoundAssignExpressionParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		operator token.TokenType
+		expected string
+	}{
+		{"a += 1", token.PLUS_ASSIGN, "a += 1;"},
+		{"a -= b * 2", token.MINUS_ASSIGN, "a -= (b * 2);"},
+		{"a *= 3", token.ASTERISK_ASSIGN, "a *= 3;"},
+		{"a /= 4", token.SLASH_ASSIGN, "a /= 4;"},
+	}
+
+	for _, tt := range tests {
+		l := lex(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		assertNoErrors(t, p)
+
+		assert.Len(t, program.Statements, 1)
+
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		assert.True(t, ok)
+
+		exp, ok := stmt.Expression.(*ast.AssignExpression)
+		assert.True(t, ok)
+
+		assertIdentifier(t, exp.Name, "a")
+		assert.Equal(t, tt.operator, exp.Token.Type)
+		assert.Equal(t, tt.expected, program.String())
+	}
+}
+
+func TestIndexAssignExpressionParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"array[0] = 1", "array[0] = 1;"},
+		{"array[i + 1] += 2", "array[(i + 1)] += 2;"},
+		{"matrix[0][1] = 3", "matrix[0][1] = 3;"},
+	}
+
+	for _, tt := range tests {
+		l := lex(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		assertNoErrors(t, p)
+
+		assert.Len(t, program.Statements, 1)
+
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		assert.True(t, ok)
+
+		_, ok = stmt.Expression.(*ast.IndexAssignExpression)
+		assert.True(t, ok)
+
+		assert.Equal(t, tt.expected, program.String())
+	}
+}
+
 func TestComments(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -750,3 +973,102 @@ func TestIndexExpressions(t *testing.T) {
 	assertIdentifier(t, indexp.Left, "array")
 	assertIntegerLiteral(t, indexp.Index, 1)
 }
+
+func TestSliceExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"array[1:3]", "array[1:3]"},
+		{"array[:2]", "array[:2]"},
+		{"array[1:]", "array[1:]"},
+		{"array[:]", "array[:]"},
+		{"array[-1:a + 1]", "array[(-1):(a + 1)]"},
+	}
+
+	for _, tt := range tests {
+		l := lex(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		assertNoErrors(t, p)
+
+		assert.Len(t, program.Statements, 1)
+
+		exp, ok := program.Statements[0].(*ast.ExpressionStatement)
+		assert.True(t, ok)
+		_, ok = exp.Expression.(*ast.SliceExpression)
+		assert.True(t, ok)
+
+		assert.Equal(t, tt.expected, program.String())
+	}
+}
+
+func TestSliceErrors(t *testing.T) {
+	l := lex("array[1:2:3]")
+	p := New(l)
+	p.ParseProgram()
+
+	errors := p.Errors()
+	assert.Len(t, errors, 3)
+	assert.Equal(t,
+		"/path/to/file:1 expected next token to be ], got : instead",
+		errors[0].String(),
+	)
+}
+
+func TestPipeExpression(t *testing.T) {
+	l := lex("list |> push(1)")
+	p := New(l)
+	program := p.ParseProgram()
+	assertNoErrors(t, p)
+
+	assert.Len(t, program.Statements, 1)
+
+	exp, ok := program.Statements[0].(*ast.ExpressionStatement)
+	assert.True(t, ok)
+	pipe, ok := exp.Expression.(*ast.PipeExpression)
+	assert.True(t, ok)
+
+	assertIdentifier(t, pipe.Left, "list")
+	call, ok := pipe.Right.(*ast.CallExpression)
+	assert.True(t, ok)
+	assertIdentifier(t, call.Function, "push")
+}
+
+func TestHashLiteral(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{}`, `{}`},
+		{`{"one": 1, "two": 1 + 1}`, `{"one": 1, "two": (1 + 1)}`},
+		{`{1: true, key: [1],}`, `{1: true, key: [1]}`},
+		{`{"nested": {"a": 1}}`, `{"nested": {"a": 1}}`},
+	}
+
+	for _, tt := range tests {
+		l := lex(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		assertNoErrors(t, p)
+
+		assert.Len(t, program.Statements, 1)
+
+		exp, ok := program.Statements[0].(*ast.ExpressionStatement)
+		assert.True(t, ok)
+		_, ok = exp.Expression.(*ast.HashLiteral)
+		assert.True(t, ok)
+
+		assert.Equal(t, tt.expected, program.String())
+	}
+}
+
+func TestHashLiteralErrors(t *testing.T) {
+	l := lex(`{"one" 1}`)
+	p := New(l)
+	p.ParseProgram()
+
+	errors := p.Errors()
+	assert.NotEmpty(t, errors)
+	assert.Equal(t, "/path/to/file:1 expected next token to be :, got INT instead", errors[0].String())
+}
@@ -0,0 +1,18 @@
+package parser
+
+import "github.com/uesteibar/lainoa/pkg/ast"
+
+func (p *Parser) parsePipeExpression(left ast.Expression) ast.Expression {
+	exp := &ast.PipeExpression{
+		Token: p.curToken,
+		Left:  left,
+	}
+
+	precedence := p.curPrecedence()
+
+	// advance to the expression on the right
+	p.nextToken()
+	exp.Right = p.parseExpression(precedence)
+
+	return exp
+}
@@ -0,0 +1,14 @@
+package parser
+
+import (
+	"github.com/uesteibar/lainoa/pkg/ast"
+)
+
+func (p *Parser) parseSpreadExpression() ast.Expression {
+	spread := &ast.SpreadExpression{Token: p.curToken}
+
+	p.nextToken()
+	spread.Value = p.parseExpression(PREFIX)
+
+	return spread
+}
@@ -0,0 +1,195 @@
+package profiler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"sort"
+)
+
+// Field numbers of the messages in pprof's profile.proto, see
+// https://github.com/google/pprof/blob/master/proto/profile.proto
+const (
+	profileSampleType    = 1
+	profileSample        = 2
+	profileLocation      = 4
+	profileFunction      = 5
+	profileStringTable   = 6
+	profileTimeNanos     = 9
+	profileDurationNanos = 10
+	profilePeriodType    = 11
+	profilePeriod        = 12
+
+	valueTypeType = 1
+	valueTypeUnit = 2
+
+	sampleLocationID = 1
+	sampleValue      = 2
+
+	locationID   = 1
+	locationLine = 4
+
+	lineFunctionID = 1
+	lineLine       = 2
+
+	functionID         = 1
+	functionName       = 2
+	functionSystemName = 3
+	functionFilename   = 4
+	functionStartLine  = 5
+)
+
+// WritePprof writes the profile in the gzipped protocol buffer format that
+// `go tool pprof` reads. Every sample is a call stack, with how many calls
+// ended in it and the self time they took.
+func (p *Profiler) WritePprof(w io.Writer) error {
+	strings := newStringTable()
+	var profile protobuf
+
+	profile.message(profileSampleType, valueType(strings, "calls", "count"))
+	profile.message(profileSampleType, valueType(strings, "time", "nanoseconds"))
+
+	for _, s := range p.sortedSamples() {
+		var msg protobuf
+		msg.packed(sampleLocationID, s.stack)
+		msg.packed(sampleValue, []uint64{uint64(s.calls), uint64(s.self.Nanoseconds())})
+		profile.message(profileSample, &msg)
+	}
+
+	// every function has a single location, where it's defined
+	for i, fn := range p.funcs {
+		id := uint64(i + 1)
+
+		var line protobuf
+		line.uint64(lineFunctionID, id)
+		line.uint64(lineLine, uint64(fn.Line))
+
+		var location protobuf
+		location.uint64(locationID, id)
+		location.message(locationLine, &line)
+		profile.message(profileLocation, &location)
+	}
+
+	for i, fn := range p.funcs {
+		// the name includes the line, so that anonymous functions aren't
+		// merged together
+		name := fn.String()
+
+		var function protobuf
+		function.uint64(functionID, uint64(i+1))
+		function.uint64(functionName, strings.index(name))
+		function.uint64(functionSystemName, strings.index(name))
+		function.uint64(functionFilename, strings.index(fn.File))
+		function.uint64(functionStartLine, uint64(fn.Line))
+		profile.message(profileFunction, &function)
+	}
+
+	profile.uint64(profileTimeNanos, uint64(p.start.UnixNano()))
+	profile.uint64(profileDurationNanos, uint64(p.duration.Nanoseconds()))
+	profile.message(profilePeriodType, valueType(strings, "time", "nanoseconds"))
+	profile.uint64(profilePeriod, 1)
+
+	// the string table goes last, once every string has been indexed
+	for _, str := range strings.strings {
+		profile.bytes(profileStringTable, []byte(str))
+	}
+
+	gz := gzip.NewWriter(w)
+	if _, err := gz.Write(profile.Bytes()); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func (p *Profiler) sortedSamples() []*sample {
+	samples := make([]*sample, 0, len(p.samples))
+	for _, s := range p.samples {
+		samples = append(samples, s)
+	}
+
+	sort.Slice(samples, func(i, j int) bool {
+		a, b := samples[i].stack, samples[j].stack
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+
+	return samples
+}
+
+func valueType(strings *stringTable, typ string, unit string) *protobuf {
+	var msg protobuf
+	msg.uint64(valueTypeType, strings.index(typ))
+	msg.uint64(valueTypeUnit, strings.index(unit))
+	return &msg
+}
+
+// stringTable deduplicates the strings in the profile, which messages
+// refer to by index. The first one has to be the empty string.
+type stringTable struct {
+	strings []string
+	indexes map[string]uint64
+}
+
+func newStringTable() *stringTable {
+	return &stringTable{strings: []string{""}, indexes: map[string]uint64{"": 0}}
+}
+
+func (t *stringTable) index(str string) uint64 {
+	i, ok := t.indexes[str]
+	if !ok {
+		i = uint64(len(t.strings))
+		t.strings = append(t.strings, str)
+		t.indexes[str] = i
+	}
+
+	return i
+}
+
+// protobuf encodes the few protocol buffer field types pprof needs.
+type protobuf struct {
+	bytes.Buffer
+}
+
+const (
+	wireVarint = 0
+	wireBytes  = 2
+)
+
+func (b *protobuf) varint(x uint64) {
+	for x >= 0x80 {
+		b.WriteByte(byte(x) | 0x80)
+		x >>= 7
+	}
+	b.WriteByte(byte(x))
+}
+
+func (b *protobuf) key(field int, wireType int) {
+	b.varint(uint64(field)<<3 | uint64(wireType))
+}
+
+func (b *protobuf) uint64(field int, x uint64) {
+	b.key(field, wireVarint)
+	b.varint(x)
+}
+
+func (b *protobuf) bytes(field int, data []byte) {
+	b.key(field, wireBytes)
+	b.varint(uint64(len(data)))
+	b.Write(data)
+}
+
+func (b *protobuf) message(field int, msg *protobuf) {
+	b.bytes(field, msg.Bytes())
+}
+
+func (b *protobuf) packed(field int, values []uint64) {
+	var data protobuf
+	for _, x := range values {
+		data.varint(x)
+	}
+	b.bytes(field, data.Bytes())
+}
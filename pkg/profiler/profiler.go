@@ -0,0 +1,169 @@
+package profiler
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Function identifies what's being profiled: a Lainoa function, by its name
+// and where it's defined, or a builtin.
+type Function struct {
+	Name    string
+	File    string
+	Line    int
+	Builtin bool
+}
+
+// Stats are the measurements for a function. Self time excludes the time
+// spent in the functions it calls, while cumulative time includes it.
+type Stats struct {
+	Function   Function
+	Calls      int
+	Self       time.Duration
+	Cumulative time.Duration
+}
+
+// Profiler measures the calls made while a program runs. Every call must
+// be wrapped between Enter and Exit.
+type Profiler struct {
+	now      func() time.Time
+	start    time.Time
+	duration time.Duration
+
+	stack  []*frame
+	active map[Function]int
+	stats  map[Function]*Stats
+
+	// samples aggregate the calls by the stack they happened in, which is
+	// what pprof needs to build call graphs
+	samples map[string]*sample
+	ids     map[Function]uint64
+	funcs   []Function
+}
+
+type frame struct {
+	fn       Function
+	start    time.Time
+	children time.Duration
+}
+
+type sample struct {
+	stack []uint64 // function ids, from the innermost call
+	calls int64
+	self  time.Duration
+}
+
+func New() *Profiler {
+	return newProfiler(time.Now)
+}
+
+func newProfiler(now func() time.Time) *Profiler {
+	return &Profiler{
+		now:     now,
+		start:   now(),
+		active:  make(map[Function]int),
+		stats:   make(map[Function]*Stats),
+		samples: make(map[string]*sample),
+		ids:     make(map[Function]uint64),
+	}
+}
+
+func (p *Profiler) Enter(fn Function) {
+	p.stack = append(p.stack, &frame{fn: fn, start: p.now()})
+	p.active[fn]++
+}
+
+func (p *Profiler) Exit() {
+	f := p.stack[len(p.stack)-1]
+	elapsed := p.now().Sub(f.start)
+	self := elapsed - f.children
+
+	stats := p.statsFor(f.fn)
+	stats.Calls++
+	stats.Self += self
+	// recursive calls are already part of the cumulative time of the
+	// outermost call, so they'd be counted twice
+	if p.active[f.fn] == 1 {
+		stats.Cumulative += elapsed
+	}
+	p.active[f.fn]--
+
+	p.recordSample(self)
+
+	p.stack = p.stack[:len(p.stack)-1]
+	if len(p.stack) > 0 {
+		p.stack[len(p.stack)-1].children += elapsed
+	}
+}
+
+// Stop marks the end of the program, to know the total time it took.
+func (p *Profiler) Stop() {
+	p.duration = p.now().Sub(p.start)
+}
+
+func (p *Profiler) Duration() time.Duration {
+	return p.duration
+}
+
+// Stats returns the measurements of every function called, sorted by self
+// time, most expensive first.
+func (p *Profiler) Stats() []Stats {
+	all := make([]Stats, 0, len(p.stats))
+	for _, stats := range p.stats {
+		all = append(all, *stats)
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Self != all[j].Self {
+			return all[i].Self > all[j].Self
+		}
+		if all[i].Cumulative != all[j].Cumulative {
+			return all[i].Cumulative > all[j].Cumulative
+		}
+		return all[i].Function.String() < all[j].Function.String()
+	})
+
+	return all
+}
+
+func (p *Profiler) statsFor(fn Function) *Stats {
+	stats, ok := p.stats[fn]
+	if !ok {
+		stats = &Stats{Function: fn}
+		p.stats[fn] = stats
+	}
+
+	return stats
+}
+
+func (p *Profiler) recordSample(self time.Duration) {
+	stack := make([]uint64, len(p.stack))
+	keys := make([]string, len(p.stack))
+	for i := range p.stack {
+		fn := p.stack[len(p.stack)-1-i].fn
+		stack[i] = p.functionID(fn)
+		keys[i] = strconv.FormatUint(stack[i], 10)
+	}
+
+	key := strings.Join(keys, ",")
+	s, ok := p.samples[key]
+	if !ok {
+		s = &sample{stack: stack}
+		p.samples[key] = s
+	}
+	s.calls++
+	s.self += self
+}
+
+func (p *Profiler) functionID(fn Function) uint64 {
+	id, ok := p.ids[fn]
+	if !ok {
+		p.funcs = append(p.funcs, fn)
+		id = uint64(len(p.funcs))
+		p.ids[fn] = id
+	}
+
+	return id
+}
@@ -0,0 +1,92 @@
+package profiler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock advances one millisecond every time it's read.
+func fakeClock() func() time.Time {
+	now := time.Unix(0, 0)
+	return func() time.Time {
+		now = now.Add(time.Millisecond)
+		return now
+	}
+}
+
+var (
+	fib    = Function{Name: "fib", File: "fib.ln", Line: 1}
+	puts   = Function{Name: "puts", Builtin: true}
+	double = Function{Name: "anonymous", File: "fib.ln", Line: 7}
+)
+
+func profileCalls() *Profiler {
+	p := newProfiler(fakeClock())
+
+	p.Enter(fib)
+	p.Enter(fib)
+	p.Enter(puts)
+	p.Exit()
+	p.Exit()
+	p.Exit()
+	p.Enter(double)
+	p.Exit()
+	p.Stop()
+
+	return p
+}
+
+func TestStats(t *testing.T) {
+	p := profileCalls()
+
+	assert.Equal(t, 9*time.Millisecond, p.Duration())
+	assert.Equal(t, []Stats{
+		{Function: fib, Calls: 2, Self: 4 * time.Millisecond, Cumulative: 5 * time.Millisecond},
+		{Function: double, Calls: 1, Self: time.Millisecond, Cumulative: time.Millisecond},
+		{Function: puts, Calls: 1, Self: time.Millisecond, Cumulative: time.Millisecond},
+	}, p.Stats())
+}
+
+func TestWriteTop(t *testing.T) {
+	p := profileCalls()
+
+	var out bytes.Buffer
+	assert.Nil(t, p.WriteTop(&out, 2))
+
+	assert.Equal(t, `Showing top 2 of 3 functions, total time 9ms
+
+     calls         self   self%          cum    cum%  function
+         2          4ms   44.4%          5ms   55.6%  fib (fib.ln:1)
+         1          1ms   11.1%          1ms   11.1%  anonymous (fib.ln:7)
+`, out.String())
+}
+
+func TestWritePprof(t *testing.T) {
+	p := profileCalls()
+
+	var out bytes.Buffer
+	assert.Nil(t, p.WritePprof(&out))
+
+	gz, err := gzip.NewReader(&out)
+	assert.Nil(t, err)
+	data, err := ioutil.ReadAll(gz)
+	assert.Nil(t, err)
+
+	for _, str := range []string{"calls", "count", "time", "nanoseconds", "fib (fib.ln:1)", "puts (builtin)", "fib.ln"} {
+		assert.Contains(t, string(data), str)
+	}
+	// fib, fib > fib, fib > fib > puts and double
+	assert.Equal(t, 4, len(p.samples))
+}
+
+func TestProtobufVarint(t *testing.T) {
+	var b protobuf
+	b.uint64(1, 300)
+
+	assert.Equal(t, []byte{0x08, 0xac, 0x02}, b.Bytes())
+}
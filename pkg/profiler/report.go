@@ -0,0 +1,53 @@
+package profiler
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"time"
+)
+
+func (f Function) String() string {
+	if f.Builtin {
+		return fmt.Sprintf("%s (builtin)", f.Name)
+	}
+
+	return fmt.Sprintf("%s (%s:%d)", f.Name, f.File, f.Line)
+}
+
+// WriteTop writes a table with the n functions that took the most self
+// time, or all of them when n is 0.
+func (p *Profiler) WriteTop(w io.Writer, n int) error {
+	out := bufio.NewWriter(w)
+
+	stats := p.Stats()
+	if n > 0 && n < len(stats) {
+		fmt.Fprintf(out, "Showing top %d of %d functions, total time %s\n\n", n, len(stats), formatDuration(p.duration))
+		stats = stats[:n]
+	} else {
+		fmt.Fprintf(out, "Showing %d functions, total time %s\n\n", len(stats), formatDuration(p.duration))
+	}
+
+	fmt.Fprintf(out, "%10s %12s %7s %12s %7s  %s\n", "calls", "self", "self%", "cum", "cum%", "function")
+	for _, s := range stats {
+		fmt.Fprintf(out, "%10d %12s %6.1f%% %12s %6.1f%%  %s\n",
+			s.Calls,
+			formatDuration(s.Self), p.percentage(s.Self),
+			formatDuration(s.Cumulative), p.percentage(s.Cumulative),
+			s.Function)
+	}
+
+	return out.Flush()
+}
+
+func (p *Profiler) percentage(d time.Duration) float64 {
+	if p.duration == 0 {
+		return 0
+	}
+
+	return 100 * float64(d) / float64(p.duration)
+}
+
+func formatDuration(d time.Duration) string {
+	return d.Round(time.Microsecond).String()
+}
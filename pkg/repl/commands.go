@@ -0,0 +1,148 @@
+package repl
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/uesteibar/lainoa/pkg/lexer"
+	"github.com/uesteibar/lainoa/pkg/object"
+	"github.com/uesteibar/lainoa/pkg/token"
+)
+
+const help = `Commands:
+
+	:env		show the bindings defined so far
+	:reset		forget every binding
+	:load file	evaluate a file, keeping its bindings
+	:save file	save the definitions entered so far to a file
+	:restore file	replace every binding with the ones saved in a file
+	:ast code	show how code is parsed
+	:tokens code	show the tokens in code
+	:time code	evaluate code and show how long it took
+	:help		show this help
+
+Input goes on in the next line while it has unclosed parens, brackets,
+braces or strings, or ends with an operator. Press Ctrl-C to discard it.`
+
+// command runs a meta-command, a line starting with a colon.
+func (r *Repl) command(line string) *object.Error {
+	name, arg := line, ""
+	if i := strings.IndexAny(line, " \t"); i >= 0 {
+		name, arg = line[:i], strings.TrimSpace(line[i+1:])
+	}
+
+	switch name {
+	case ":env":
+		r.printEnv()
+	case ":reset":
+		r.reset()
+		fmt.Fprintln(r.out, "Every binding is gone")
+	case ":load":
+		if arg == "" {
+			fmt.Fprintln(r.out, "You need to tell me what file to load: :load path/to/file.ln")
+			return nil
+		}
+		return r.load(arg, false)
+	case ":save":
+		if arg == "" {
+			fmt.Fprintln(r.out, "You need to tell me where to save the session: :save path/to/session.ln")
+			return nil
+		}
+		r.save(arg)
+	case ":restore":
+		if arg == "" {
+			fmt.Fprintln(r.out, "You need to tell me what session to restore: :restore path/to/session.ln")
+			return nil
+		}
+		return r.load(arg, true)
+	case ":ast":
+		if program, ok := r.parse(arg, "repl"); ok {
+			for _, stmt := range program.Statements {
+				fmt.Fprintln(r.out, stmt.String())
+			}
+		}
+	case ":tokens":
+		l := lexer.New(arg, "repl")
+		for t := l.NextToken(); t.Type != token.EOF; t = l.NextToken() {
+			fmt.Fprintf(r.out, "%-10s %s\n", t.Type, t.Literal)
+		}
+	case ":time":
+		program, ok := r.parse(arg, "repl")
+		if !ok {
+			return nil
+		}
+
+		start := time.Now()
+		evaluated := r.eval(program, arg)
+		took := time.Since(start)
+
+		if err := r.print(evaluated); err != nil {
+			return err
+		}
+		fmt.Fprintln(r.out, "took", took)
+	case ":help":
+		fmt.Fprintln(r.out, help)
+	default:
+		fmt.Fprintf(r.out, "Unknown command %q, type :help to see the available ones\n", name)
+	}
+
+	return nil
+}
+
+func (r *Repl) printEnv() {
+	bindings := r.env.Bindings()
+	names := make([]string, 0, len(bindings))
+	for name := range bindings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(r.out, "%s = %s\n", name, object.Describe(bindings[name]))
+	}
+}
+
+// load evaluates a file in the REPL's environment, so what it defines can
+// be used afterwards. When restoring, it's evaluated in a new environment
+// that replaces the REPL's one only if the file runs without errors.
+func (r *Repl) load(path string, restoring bool) *object.Error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		fmt.Fprintln(r.out, "Error reading file", err)
+		return nil
+	}
+
+	program, ok := r.parse(string(data), path)
+	if !ok {
+		return nil
+	}
+	env, definitions := r.env, r.definitions
+	if restoring {
+		r.reset()
+	}
+
+	evaluated := r.eval(program, strings.TrimRight(string(data), "\n"))
+	if err, ok := evaluated.(*object.Error); ok {
+		if restoring {
+			r.env, r.definitions = env, definitions
+		}
+		if err.Exit {
+			return err
+		}
+		fmt.Fprintln(r.out, err.Inspect())
+		if restoring {
+			fmt.Fprintln(r.out, "Couldn't restore", path+", the session is unchanged")
+		}
+		return nil
+	}
+
+	if restoring {
+		fmt.Fprintln(r.out, "Restored", path)
+	} else {
+		fmt.Fprintln(r.out, "Loaded", path)
+	}
+	return nil
+}
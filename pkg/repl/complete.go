@@ -0,0 +1,74 @@
+package repl
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/uesteibar/lainoa/pkg/evaluator"
+	"github.com/uesteibar/lainoa/pkg/token"
+)
+
+var commands = []string{":ast", ":env", ":help", ":load", ":reset", ":restore", ":save", ":time", ":tokens"}
+
+// completer completes keywords, builtins and the names bound in the REPL,
+// or the meta-commands at the start of a line.
+type completer struct {
+	repl *Repl
+}
+
+// Do implements readline.AutoCompleter, returning the rest of every
+// candidate that starts with the word before the cursor.
+func (c *completer) Do(line []rune, pos int) ([][]rune, int) {
+	start := pos
+	for start > 0 && isIdentifierRune(line[start-1]) {
+		start--
+	}
+
+	var candidates []string
+	if start == 1 && line[0] == ':' && !c.repl.Pending() {
+		start = 0
+		candidates = commands
+	} else if start < pos {
+		candidates = c.names()
+	}
+
+	word := string(line[start:pos])
+	completions := [][]rune{}
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, word) {
+			completions = append(completions, []rune(candidate[len(word):]))
+		}
+	}
+
+	return completions, pos - start
+}
+
+// names returns every name that can be used in the REPL, sorted.
+func (c *completer) names() []string {
+	seen := map[string]bool{}
+	names := []string{}
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	for _, keyword := range token.Keywords() {
+		add(keyword)
+	}
+	for _, builtin := range evaluator.Builtins() {
+		add(builtin)
+	}
+	for name := range c.repl.env.Bindings() {
+		add(name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func isIdentifierRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
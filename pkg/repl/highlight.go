@@ -0,0 +1,76 @@
+package repl
+
+import (
+	"strings"
+
+	"github.com/uesteibar/lainoa/pkg/lexer"
+	"github.com/uesteibar/lainoa/pkg/token"
+)
+
+const (
+	reset   = "\033[0m"
+	red     = "\033[31m"
+	green   = "\033[32m"
+	yellow  = "\033[33m"
+	magenta = "\033[35m"
+	cyan    = "\033[36m"
+	gray    = "\033[90m"
+)
+
+var colors = map[token.TokenType]string{
+	token.FUNCTION: magenta,
+	token.LET:      magenta,
+	token.RETURN:   magenta,
+	token.IF:       magenta,
+	token.ELSE:     magenta,
+	token.TRUE:     yellow,
+	token.FALSE:    yellow,
+	token.NIL:      yellow,
+	token.INT:      cyan,
+	token.STRING:   green,
+	token.COMMENT:  gray,
+	token.ILLEGAL:  red,
+}
+
+// painter highlights the line being typed by the type of its tokens.
+type painter struct {
+	repl *Repl
+}
+
+// Paint implements readline.Painter.
+func (p *painter) Paint(line []rune, _ int) []rune {
+	if len(line) == 0 || (line[0] == ':' && !p.repl.Pending()) {
+		return line
+	}
+
+	return []rune(highlight(string(line)))
+}
+
+// highlight wraps every token of input that has a color in it, leaving the
+// rest as it is.
+func highlight(input string) string {
+	var out strings.Builder
+	l := lexer.New(input, "repl")
+	end := 0
+
+	for t := l.NextToken(); t.Type != token.EOF; t = l.NextToken() {
+		start := end
+		for start < len(input) && strings.ContainsRune(" \t\r\n", rune(input[start])) {
+			start++
+		}
+		out.WriteString(input[end:start])
+		end = l.Offset()
+
+		color, ok := colors[t.Type]
+		if !ok {
+			out.WriteString(input[start:end])
+			continue
+		}
+		out.WriteString(color)
+		out.WriteString(input[start:end])
+		out.WriteString(reset)
+	}
+	out.WriteString(input[end:])
+
+	return out.String()
+}
@@ -0,0 +1,72 @@
+package repl
+
+import (
+	"strings"
+
+	"github.com/uesteibar/lainoa/pkg/lexer"
+	"github.com/uesteibar/lainoa/pkg/token"
+)
+
+// Tokens that can't end an expression, so the input goes on in the next
+// line when it ends with one of them.
+var continuations = map[token.TokenType]bool{
+	token.ASSIGN:          true,
+	token.PLUS:            true,
+	token.MINUS:           true,
+	token.ASTERISK:        true,
+	token.SLASH:           true,
+	token.BANG:            true,
+	token.LT:              true,
+	token.GT:              true,
+	token.EQ:              true,
+	token.NOT_EQ:          true,
+	token.PIPE:            true,
+	token.COMPOSE:         true,
+	token.PLUS_ASSIGN:     true,
+	token.MINUS_ASSIGN:    true,
+	token.ASTERISK_ASSIGN: true,
+	token.SLASH_ASSIGN:    true,
+	token.COMMA:           true,
+	token.COLON:           true,
+	token.FUNCTION:        true,
+	token.LET:             true,
+	token.IF:              true,
+	token.ELSE:            true,
+}
+
+// incomplete tells whether input needs more lines to be parsed: it has
+// unclosed parens, brackets, braces or strings, or ends with an operator.
+func incomplete(input string) bool {
+	l := lexer.New(input, "repl")
+	depth := 0
+	last := token.Token{Type: token.EOF}
+	// strings can't have quotes inside, so every string has two of them
+	// unless it's unclosed
+	quotes := strings.Count(input, `"`)
+
+	for t := l.NextToken(); t.Type != token.EOF; t = l.NextToken() {
+		switch t.Type {
+		case token.LPAREN, token.LBRACE, token.LBRACKET:
+			depth++
+		case token.RPAREN, token.RBRACE, token.RBRACKET:
+			depth--
+		case token.STRING:
+			quotes -= 2
+		case token.COMMENT:
+			quotes -= strings.Count(t.Literal, `"`)
+			continue
+		}
+		last = t
+	}
+
+	if quotes < 0 {
+		return true
+	}
+
+	// more closing than opening ones is an error, more lines won't fix it
+	if depth != 0 {
+		return depth > 0
+	}
+
+	return continuations[last.Type]
+}
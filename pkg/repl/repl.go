@@ -2,8 +2,13 @@ package repl
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/chzyer/readline"
+	"github.com/uesteibar/lainoa/pkg/ast"
 	"github.com/uesteibar/lainoa/pkg/evaluator"
 	"github.com/uesteibar/lainoa/pkg/lexer"
 	"github.com/uesteibar/lainoa/pkg/object"
@@ -12,45 +17,193 @@ import (
 
 const PROMPT = "⛅️ >> "
 
-func Start() {
-	l, err := readline.NewEx(&readline.Config{
+// CONTINUATION_PROMPT is shown while the input so far is incomplete.
+const CONTINUATION_PROMPT = "   .. "
+
+// Config holds the options for an interactive REPL.
+type Config struct {
+	// Colors turns syntax highlighting on.
+	Colors bool
+	// HistoryFile is where the input is remembered between sessions, none
+	// when it's empty.
+	HistoryFile string
+}
+
+// DefaultHistoryFile returns the history file in the user's configuration
+// directory, or an empty string if there's none.
+func DefaultHistoryFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "lainoa", "repl_history")
+}
+
+func Start(config Config) {
+	r := New(os.Stdout)
+
+	if config.HistoryFile != "" {
+		if err := os.MkdirAll(filepath.Dir(config.HistoryFile), 0700); err != nil {
+			fmt.Fprintln(os.Stderr, "Can't keep the history:", err)
+			config.HistoryFile = ""
+		}
+	}
+
+	rlConfig := &readline.Config{
 		Prompt:          PROMPT,
-		HistoryFile:     "/tmp/lainoa_repl_history.tmp",
+		HistoryFile:     config.HistoryFile,
 		InterruptPrompt: "^C",
 		EOFPrompt:       "exit",
+		AutoComplete:    &completer{repl: r},
 
 		HistorySearchFold: true,
-	})
+	}
+	if config.Colors {
+		rlConfig.Painter = &painter{repl: r}
+	}
 
+	rl, err := readline.NewEx(rlConfig)
 	if err != nil {
 		panic(err)
 	}
-	defer l.Close()
-
-	env := object.NewEnvironment()
+	defer rl.Close()
 
 	for {
-		line, err := l.Readline()
+		rl.SetPrompt(r.Prompt())
+		line, err := rl.Readline()
+		if err == readline.ErrInterrupt && r.Pending() {
+			r.Discard()
+			continue
+		}
 		if err != nil {
 			return
 		}
 
-		l := lexer.New(line, "repl")
-		p := parser.New(l)
-		program := p.ParseProgram()
-
-		if len(p.Errors()) > 0 {
-			fmt.Println("Oops! Something is wrong here:")
-			fmt.Println("  parser errors:")
-			for _, err := range p.Errors() {
-				fmt.Println(fmt.Sprintf("- %s\n", err.String()))
-			}
-		} else {
-			evaluated := evaluator.Eval(program, env)
-
-			if evaluated != nil {
-				fmt.Println(evaluated.Inspect())
-			}
+		if err := r.Feed(line); err != nil {
+			rl.Close()
+			os.Exit(err.ExitCode)
+		}
+	}
+}
+
+// Repl evaluates the input it's fed line by line, keeping the bindings
+// between them.
+type Repl struct {
+	env   *object.Environment
+	out   io.Writer
+	lines []string
+
+	// definitions is the input that bound or changed names, to save it
+	definitions []string
+}
+
+func New(out io.Writer) *Repl {
+	return &Repl{env: object.NewEnvironment(), out: out}
+}
+
+// Prompt returns the prompt for the next line.
+func (r *Repl) Prompt() string {
+	if r.Pending() {
+		return CONTINUATION_PROMPT
+	}
+
+	return PROMPT
+}
+
+// Pending tells whether there's incomplete input waiting for more lines.
+func (r *Repl) Pending() bool {
+	return len(r.lines) > 0
+}
+
+// Discard forgets the incomplete input.
+func (r *Repl) Discard() {
+	r.lines = nil
+}
+
+// Feed takes a line of input, evaluating it once it's complete. It returns
+// the error when the code calls exit.
+func (r *Repl) Feed(line string) *object.Error {
+	if !r.Pending() && strings.HasPrefix(strings.TrimSpace(line), ":") {
+		return r.command(strings.TrimSpace(line))
+	}
+
+	r.lines = append(r.lines, line)
+	input := strings.Join(r.lines, "\n")
+	if incomplete(input) {
+		return nil
+	}
+	r.lines = nil
+
+	program, ok := r.parse(input, "repl")
+	if !ok {
+		return nil
+	}
+
+	return r.print(r.eval(program, input))
+}
+
+// eval evaluates a program parsed from source, remembering the source
+// when it defines something. When it fails, the statements that ran are
+// remembered, as what they bound is kept.
+func (r *Repl) eval(program *ast.Program, source string) object.Object {
+	progress := newProgress(program)
+	evaluator.SetTracer(progress)
+	evaluated := evaluator.Eval(program, r.env)
+	evaluator.SetTracer(nil)
+
+	if _, failed := evaluated.(*object.Error); !failed {
+		if defines(program.Statements) {
+			r.definitions = append(r.definitions, source)
+		}
+		return evaluated
+	}
+
+	ran := progress.ran(program, r.env)
+	statements := make([]ast.Statement, len(ran))
+	for i, idx := range ran {
+		statements[i] = program.Statements[idx]
+	}
+	if !defines(statements) {
+		return evaluated
+	}
+
+	if sources := statementSources(source, program); sources != nil {
+		definition := make([]string, len(ran))
+		for i, idx := range ran {
+			definition[i] = sources[idx]
+		}
+		r.definitions = append(r.definitions, strings.Join(definition, "\n"))
+	}
+
+	return evaluated
+}
+
+// parse parses input, printing the errors if there are any.
+func (r *Repl) parse(input string, filename string) (*ast.Program, bool) {
+	p := parser.New(lexer.New(input, filename))
+	program := p.ParseProgram()
+
+	if len(p.Errors()) > 0 {
+		fmt.Fprintln(r.out, "Oops! Something is wrong here:")
+		fmt.Fprintln(r.out, "  parser errors:")
+		for _, err := range p.Errors() {
+			fmt.Fprintln(r.out, fmt.Sprintf("- %s\n", err.String()))
 		}
+		return nil, false
 	}
+
+	return program, true
+}
+
+// print shows the result of an evaluation, returning it if it's an exit.
+func (r *Repl) print(evaluated object.Object) *object.Error {
+	if err, ok := evaluated.(*object.Error); ok && err.Exit {
+		return err
+	}
+	if evaluated != nil {
+		fmt.Fprintln(r.out, evaluated.Inspect())
+	}
+
+	return nil
 }
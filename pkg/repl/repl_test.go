@@ -0,0 +1,254 @@
+package repl
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIncomplete(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"1 + 2", false},
+		{"let a = fun(x) {", true},
+		{"let a = fun(x) {\n  x * 2\n}", false},
+		{"[1, 2,", true},
+		{"[1, 2, 3]", false},
+		{`{"a": 1,`, true},
+		{"puts(1", true},
+		{"1 +", true},
+		{"[1, 2] |>", true},
+		{"let a =", true},
+		{"a += 1", false},
+		{"1)", false},
+		{`"unclosed`, true},
+		{`"closed"`, false},
+		{`"a" # a "comment`, false},
+		{"let a = 1 # a { comment", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, incomplete(tt.input), tt.input)
+	}
+}
+
+func feed(r *Repl, lines ...string) {
+	for _, line := range lines {
+		r.Feed(line)
+	}
+}
+
+func TestMultiLineInput(t *testing.T) {
+	var out bytes.Buffer
+	r := New(&out)
+
+	feed(r, "let double = fun(x) {", "  x * 2")
+	assert.True(t, r.Pending())
+	assert.Equal(t, CONTINUATION_PROMPT, r.Prompt())
+	assert.Empty(t, out.String())
+
+	feed(r, "}", "[1, 2] |>", "  map(double)")
+	assert.False(t, r.Pending())
+	assert.Equal(t, PROMPT, r.Prompt())
+	assert.Equal(t, "fn double(x) {\n(x * 2)\n}\n[2, 4]\n", out.String())
+
+	out.Reset()
+	feed(r, "let broken = [1,")
+	r.Discard()
+	feed(r, "1 + 1")
+	assert.Equal(t, "2\n", out.String())
+}
+
+func TestCommands(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lainoa-repl")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	lib := filepath.Join(dir, "lib.ln")
+	assert.NoError(t, ioutil.WriteFile(lib, []byte("let triple = fun(x) { x * 3 }\n"), 0644))
+
+	tests := []struct {
+		lines    []string
+		expected string
+	}{
+		{[]string{"let b = 2", "let a = 1", ":env"}, "2\n1\na = 1\nb = 2\n"},
+		{[]string{"let add = fun(a, b) { a + b }", ":env"}, "fn add(a, b) {\n(a + b)\n}\nadd = fn add(a, b)\n"},
+		{[]string{"let a = 1", ":reset", ":env", "a"}, "1\nEvery binding is gone\nERROR: identifier not found: a\n"},
+		{[]string{":load " + lib, "triple(2)"}, "Loaded " + lib + "\n6\n"},
+		{[]string{":load " + filepath.Join(dir, "missing.ln")}, "Error reading file"},
+		{[]string{":ast 1 + 2 * 3; -a"}, "(1 + (2 * 3))\n(-a)\n"},
+		{[]string{":ast let = 1"}, "Oops! Something is wrong here:"},
+		{[]string{":tokens let a = [1]"}, "LET        let\nIDENT      a\n=          =\n[          [\nINT        1\n]          ]\n"},
+		{[]string{":help"}, "Commands:"},
+		{[]string{":what"}, "Unknown command \":what\", type :help to see the available ones\n"},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		r := New(&out)
+		feed(r, tt.lines...)
+
+		assert.True(t, strings.HasPrefix(out.String(), tt.expected), "%v: %q", tt.lines, out.String())
+	}
+}
+
+func TestTimeCommand(t *testing.T) {
+	var out bytes.Buffer
+	r := New(&out)
+	feed(r, ":time 1 + 2")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.Equal(t, "3", lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], "took "), lines[1])
+}
+
+func TestExit(t *testing.T) {
+	var out bytes.Buffer
+	r := New(&out)
+
+	err := r.Feed("exit(3)")
+	if assert.NotNil(t, err) {
+		assert.Equal(t, 3, err.ExitCode)
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	var out bytes.Buffer
+	r := New(&out)
+	feed(r, "let reduced = 1", "let rest = 2")
+	c := &completer{repl: r}
+
+	tests := []struct {
+		line     string
+		expected []string
+		length   int
+	}{
+		{"re", []string{"ad_file", "duce", "duced", "move", "peat", "place", "st", "turn"}, 2},
+		{"[1] |> fil", []string{"ter"}, 3},
+		{"le", []string{"n", "t"}, 2},
+		{"let x = ", []string{}, 0},
+		{":t", []string{"ime", "okens"}, 2},
+		{":", []string{"ast", "env", "help", "load", "reset", "restore", "save", "time", "tokens"}, 1},
+	}
+
+	for _, tt := range tests {
+		completions, length := c.Do([]rune(tt.line), len([]rune(tt.line)))
+
+		actual := []string{}
+		for _, completion := range completions {
+			actual = append(actual, string(completion))
+		}
+		assert.Equal(t, tt.expected, actual, tt.line)
+		assert.Equal(t, tt.length, length, tt.line)
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`let a = fun(x) { if (x) { "ñ" } else { nil } } # ok`,
+			magenta + "let" + reset + " a = " + magenta + "fun" + reset + "(x) { " + magenta + "if" + reset + " (x) { " +
+				green + `"ñ"` + reset + " } " + magenta + "else" + reset + " { " + yellow + "nil" + reset + " } } " +
+				gray + "# ok" + reset,
+		},
+		{"  1 + true € ", "  " + cyan + "1" + reset + " + " + yellow + "true" + reset + " " + red + "€" + reset + " "},
+		{`"unclosed`, green + `"unclosed` + reset},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, highlight(tt.input), tt.input)
+	}
+}
+
+func TestSaveAndRestore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lainoa-repl")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	session := filepath.Join(dir, "session.ln")
+
+	var out bytes.Buffer
+	r := New(&out)
+	feed(r,
+		"let double = fun(x) {", "  x * 2", "}",
+		"let total = 1",
+		"puts(total)",
+		"let broken = missing",
+		"total = double(total)",
+		"let nums = [1, 2]; nums[0] = 5",
+		":save "+session,
+	)
+	assert.Contains(t, out.String(), "Saved 4 definitions to "+session)
+
+	data, err := ioutil.ReadFile(session)
+	assert.NoError(t, err)
+	assert.Equal(t, "let double = fun(x) {\n  x * 2\n}\nlet total = 1\ntotal = double(total)\nlet nums = [1, 2]; nums[0] = 5\n", string(data))
+
+	out.Reset()
+	r = New(&out)
+	feed(r, "let other = 1", ":restore "+filepath.Join(dir, "missing.ln"), "other")
+	assert.True(t, strings.HasSuffix(out.String(), "\n1\n"), out.String())
+
+	out.Reset()
+	feed(r, ":restore "+session, ":env")
+	assert.Equal(t, "Restored "+session+"\ndouble = fn double(x)\nnums = [5, 2]\ntotal = 2\n", out.String())
+
+	out.Reset()
+	feed(r, "let more = total + 1", ":save "+session, ":reset", ":save "+session)
+	assert.Contains(t, out.String(), "Saved 2 definitions")
+	assert.Contains(t, out.String(), "Saved 0 definitions")
+}
+
+func TestSaveFailedInput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lainoa-repl")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	session := filepath.Join(dir, "session.ln")
+
+	var out bytes.Buffer
+	r := New(&out)
+	feed(r,
+		"let a = 1; missing; let b = 2",
+		"puts(a); missing",
+		"missing(); fun f() { a }",
+		"let c = [1] # a; comment",
+		"c[0] = 2; c[1] = 3",
+		":save "+session,
+	)
+
+	data, err := ioutil.ReadFile(session)
+	assert.NoError(t, err)
+	assert.Equal(t, "let a = 1\nfun f() { a }\nlet c = [1] # a; comment\nc[0] = 2\n", string(data))
+
+	out.Reset()
+	r = New(&out)
+	feed(r, ":restore "+session, ":env")
+	assert.Equal(t, "Restored "+session+"\na = 1\nc = [2]\nf = fn f()\n", out.String())
+}
+
+func TestRestoreFailure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lainoa-repl")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	session := filepath.Join(dir, "session.ln")
+	assert.NoError(t, ioutil.WriteFile(session, []byte("let restored = 1\nmissing\n"), 0644))
+
+	var out bytes.Buffer
+	r := New(&out)
+	feed(r, "let kept = 1")
+	out.Reset()
+	feed(r, ":restore "+session, ":env", ":save "+filepath.Join(dir, "saved.ln"))
+
+	assert.Equal(t, "ERROR: identifier not found: missing\nCouldn't restore "+session+", the session is unchanged\nkept = 1\nSaved 1 definitions to "+filepath.Join(dir, "saved.ln")+"\n", out.String())
+}
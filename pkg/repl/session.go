@@ -0,0 +1,129 @@
+package repl
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/uesteibar/lainoa/pkg/ast"
+	"github.com/uesteibar/lainoa/pkg/lexer"
+	"github.com/uesteibar/lainoa/pkg/object"
+	"github.com/uesteibar/lainoa/pkg/parser"
+)
+
+// defines tells whether statements bind or change names, which is what a
+// saved session needs to replay.
+func defines(statements []ast.Statement) bool {
+	for _, stmt := range statements {
+		switch stmt := stmt.(type) {
+		case *ast.LetStatement, *ast.FunctionDeclaration:
+			return true
+		case *ast.ExpressionStatement:
+			switch stmt.Expression.(type) {
+			case *ast.AssignExpression, *ast.IndexAssignExpression:
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// save writes the input that defined something so far to path, as source
+// that restore can evaluate again.
+func (r *Repl) save(path string) {
+	source := strings.Join(r.definitions, "\n")
+	if source != "" {
+		source += "\n"
+	}
+
+	if err := ioutil.WriteFile(path, []byte(source), 0644); err != nil {
+		fmt.Fprintln(r.out, "Error saving the session", err)
+		return
+	}
+
+	fmt.Fprintf(r.out, "Saved %d definitions to %s\n", len(r.definitions), path)
+}
+
+// progress is the Tracer following the evaluation of an input, to know
+// which of its statements ran when it fails.
+type progress struct {
+	statements map[ast.Statement]int
+	// started is how many statements of the input started running
+	started int
+}
+
+func newProgress(program *ast.Program) *progress {
+	p := &progress{statements: make(map[ast.Statement]int, len(program.Statements))}
+	for i, stmt := range program.Statements {
+		p.statements[stmt] = i
+	}
+
+	return p
+}
+
+func (p *progress) Statement(stmt ast.Statement, env *object.Environment) *object.Error {
+	if i, ok := p.statements[stmt]; ok {
+		p.started = i + 1
+	}
+	return nil
+}
+
+func (p *progress) Call(fn *object.Function) {}
+
+func (p *progress) Return() {}
+
+// ran returns the statements of a program that failed which bound or
+// changed something: the ones before the statement that failed, and the
+// function declarations that were bound when hoisting them.
+func (p *progress) ran(program *ast.Program, env *object.Environment) []int {
+	ran := []int{}
+	for i, stmt := range program.Statements {
+		if i < p.started-1 {
+			ran = append(ran, i)
+		} else if decl, ok := stmt.(*ast.FunctionDeclaration); ok {
+			if fn, ok := env.Bindings()[decl.Name.Value].(*object.Function); ok && fn.Literal == decl.Function {
+				ran = append(ran, i)
+			}
+		}
+	}
+
+	return ran
+}
+
+// statementSources splits source into the source of each of the program's
+// statements. They end before a `;` or a line break, where the source
+// before parses to the same statements. It returns nil when it can't tell
+// where they end.
+func statementSources(source string, program *ast.Program) []string {
+	sources := []string{}
+	start := 0
+	for end := 0; end <= len(source) && len(sources) < len(program.Statements); end++ {
+		if end < len(source) && source[end] != ';' && source[end] != '\n' {
+			continue
+		}
+
+		p := parser.New(lexer.New(source[:end], "repl"))
+		parsed := p.ParseProgram()
+		if len(p.Errors()) > 0 || len(parsed.Statements) != len(sources)+1 {
+			continue
+		}
+		want := &ast.Program{Statements: program.Statements[:len(sources)+1]}
+		if parsed.String() != want.String() {
+			continue
+		}
+
+		sources = append(sources, strings.Trim(source[start:end], " \t\r\n;"))
+		start = end
+	}
+
+	if len(sources) != len(program.Statements) {
+		return nil
+	}
+	return sources
+}
+
+func (r *Repl) reset() {
+	r.env = object.NewEnvironment()
+	r.definitions = nil
+}
@@ -2,36 +2,88 @@ package runner
 
 import (
 	"fmt"
+	"io"
 	"io/ioutil"
+	"os"
 
 	"github.com/uesteibar/lainoa/pkg/evaluator"
 	"github.com/uesteibar/lainoa/pkg/lexer"
 	"github.com/uesteibar/lainoa/pkg/object"
+	"github.com/uesteibar/lainoa/pkg/optimizer"
 	"github.com/uesteibar/lainoa/pkg/parser"
 )
 
-func Start(filepath string) {
-	data, err := ioutil.ReadFile(filepath)
+// STDIN is the path that makes Start read the program from stdin.
+const STDIN = "-"
+
+var optimize bool
+
+// SetOptimize makes programs go through the optimizer before they run.
+func SetOptimize(enabled bool) {
+	optimize = enabled
+}
+
+// Start runs the program in filepath, or in stdin when filepath is STDIN,
+// making args available to it, and returns the exit status for the process.
+func Start(filepath string, args []string) int {
+	var data []byte
+	var err error
+	if filepath == STDIN {
+		data, err = ioutil.ReadAll(os.Stdin)
+		filepath = "stdin"
+	} else {
+		data, err = ioutil.ReadFile(filepath)
+	}
 
 	if err != nil {
-		fmt.Println("Error reading file", err)
+		fmt.Fprintln(os.Stderr, "Error reading file", err)
+		return 1
 	}
 
-	l := lexer.New(string(data), filepath)
+	return run(string(data), filepath, args, nil, os.Stderr)
+}
+
+// Eval runs source as a program, printing the value it evaluates to, and
+// returns the exit status for the process.
+func Eval(source string, args []string) int {
+	return run(source, "eval", args, os.Stdout, os.Stderr)
+}
+
+// run evaluates source, writing the value it evaluates to into out unless
+// it's nil, and the errors into errOut.
+func run(source string, filename string, args []string, out io.Writer, errOut io.Writer) int {
+	l := lexer.New(source, filename)
 	p := parser.New(l)
 	program := p.ParseProgram()
 
 	if len(p.Errors()) > 0 {
-		fmt.Println("Oops! Something is wrong here:")
-		fmt.Println("  parser errors:")
+		fmt.Fprintln(errOut, "Oops! Something is wrong here:")
+		fmt.Fprintln(errOut, "  parser errors:")
 		for _, err := range p.Errors() {
-			fmt.Println(fmt.Sprintf("- %s\n", err.String()))
+			fmt.Fprintln(errOut, fmt.Sprintf("- %s\n", err.String()))
 		}
-	} else {
-		env := object.NewEnvironment()
-		evaluated := evaluator.Eval(program, env)
-		if object.IsError(evaluated) {
-			fmt.Println(evaluated.Inspect())
+		return 1
+	}
+
+	if optimize {
+		program = optimizer.Optimize(program)
+	}
+
+	evaluator.SetArgs(args)
+	env := object.NewEnvironment()
+	evaluated := evaluator.Eval(program, env)
+	if err, ok := evaluated.(*object.Error); ok {
+		if err.Exit {
+			return err.ExitCode
 		}
+
+		fmt.Fprintln(errOut, err.Inspect())
+		return 1
+	}
+
+	if out != nil && evaluated != nil && evaluated != evaluator.NIL {
+		fmt.Fprintln(out, evaluated.Inspect())
 	}
+
+	return 0
 }
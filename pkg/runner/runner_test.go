@@ -0,0 +1,63 @@
+package runner
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		source         string
+		expectedStatus int
+		expectedOut    string
+		expectedErr    string
+	}{
+		{"1 + 2", 0, "3\n", ""},
+		{`let a = [1, 2]; a |> map(fun(x) { x * 2 })`, 0, "[2, 4]\n", ""},
+		{"nil", 0, "", ""},
+		{"let a = 1", 0, "1\n", ""},
+		{"#!/usr/bin/env lainoa\nargs()", 0, "[\"a\", \"b\"]\n", ""},
+		{"exit(4)", 4, "", ""},
+		{"missing", 1, "", "ERROR: identifier not found: missing\n"},
+		{"let = 1", 1, "", "Oops! Something is wrong here:\n  parser errors:\n"},
+	}
+
+	for _, tt := range tests {
+		var out, errOut bytes.Buffer
+		status := run(tt.source, "eval", []string{"a", "b"}, &out, &errOut)
+
+		assert.Equal(t, tt.expectedStatus, status, tt.source)
+		assert.Equal(t, tt.expectedOut, out.String(), tt.source)
+		assert.Contains(t, errOut.String(), tt.expectedErr, tt.source)
+		if tt.expectedErr == "" {
+			assert.Empty(t, errOut.String(), tt.source)
+		}
+	}
+}
+
+func TestRunOptimized(t *testing.T) {
+	SetOptimize(true)
+	defer SetOptimize(false)
+
+	tests := []struct {
+		source         string
+		expectedStatus int
+		expectedOut    string
+		expectedErr    string
+	}{
+		{"60 * 60 * 24", 0, "86400\n", ""},
+		{`if (true) { "a" + "b" } else { 1 }`, 0, "\"ab\"\n", ""},
+		{`1 + "a"`, 1, "", "ERROR: type mismatch: INTEGER + STRING\n"},
+	}
+
+	for _, tt := range tests {
+		var out, errOut bytes.Buffer
+		status := run(tt.source, "eval", []string{}, &out, &errOut)
+
+		assert.Equal(t, tt.expectedStatus, status, tt.source)
+		assert.Equal(t, tt.expectedOut, out.String(), tt.source)
+		assert.Equal(t, tt.expectedErr, errOut.String(), tt.source)
+	}
+}
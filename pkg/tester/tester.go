@@ -0,0 +1,160 @@
+package tester
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/uesteibar/lainoa/pkg/ast"
+	"github.com/uesteibar/lainoa/pkg/evaluator"
+	"github.com/uesteibar/lainoa/pkg/lexer"
+	"github.com/uesteibar/lainoa/pkg/object"
+	"github.com/uesteibar/lainoa/pkg/parser"
+	"github.com/uesteibar/lainoa/pkg/token"
+)
+
+const (
+	testFileSuffix = "_test.ln"
+	testPrefix     = "test_"
+)
+
+// Summary counts the tests that ran.
+type Summary struct {
+	Passed int
+	Failed int
+}
+
+// Run runs every test in the `*_test.ln` files found in path, which can be
+// a directory or a single file. Only tests whose name matches filter run,
+// when it's given. The results are written to out.
+func Run(path string, filter *regexp.Regexp, out io.Writer) (Summary, error) {
+	files, err := findTestFiles(path)
+	if err != nil {
+		return Summary{}, err
+	}
+
+	summary := Summary{}
+	for _, file := range files {
+		runFile(file, filter, out, &summary)
+	}
+
+	return summary, nil
+}
+
+func findTestFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	files := []string{}
+	err = filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && strings.HasSuffix(file, testFileSuffix) {
+			files = append(files, file)
+		}
+		return nil
+	})
+
+	return files, err
+}
+
+func runFile(file string, filter *regexp.Regexp, out io.Writer, summary *Summary) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		fmt.Fprintf(out, "FAIL %s\n    %s\n", file, err)
+		summary.Failed++
+		return
+	}
+
+	p := parser.New(lexer.New(string(data), file))
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		fmt.Fprintf(out, "FAIL %s\n", file)
+		for _, err := range p.Errors() {
+			fmt.Fprintf(out, "    %s\n", err.String())
+		}
+		summary.Failed++
+		return
+	}
+
+	for _, test := range findTests(program) {
+		if filter != nil && !filter.MatchString(test.Value) {
+			continue
+		}
+
+		if err := runTest(program, test); err != nil {
+			fmt.Fprintf(out, "FAIL %s %s\n    %s\n", file, test.Value, describeFailure(err))
+			summary.Failed++
+		} else {
+			fmt.Fprintf(out, "ok   %s %s\n", file, test.Value)
+			summary.Passed++
+		}
+	}
+}
+
+// findTests returns the names of the top-level test functions, declared
+// either as `fun test_x() {}` or `let test_x = fun() {}`.
+func findTests(program *ast.Program) []*ast.Identifier {
+	tests := []*ast.Identifier{}
+
+	for _, stmt := range program.Statements {
+		switch stmt := stmt.(type) {
+		case *ast.FunctionDeclaration:
+			if strings.HasPrefix(stmt.Name.Value, testPrefix) {
+				tests = append(tests, stmt.Name)
+			}
+		case *ast.LetStatement:
+			if _, ok := stmt.Value.(*ast.FunctionLiteral); ok && strings.HasPrefix(stmt.Name.Value, testPrefix) {
+				tests = append(tests, stmt.Name)
+			}
+		}
+	}
+
+	return tests
+}
+
+// runTest evaluates the program in a fresh environment, so that tests can't
+// affect each other, and then calls the test function.
+func runTest(program *ast.Program, test *ast.Identifier) *object.Error {
+	env := object.NewEnvironment()
+	if err, ok := evaluator.Eval(program, env).(*object.Error); ok {
+		return err
+	}
+
+	call := &ast.CallExpression{
+		Token:    token.Token{Type: token.LPAREN, Literal: "(", Metadata: test.Token.Metadata},
+		Function: test,
+	}
+	res := evaluator.Eval(call, env)
+	if err, ok := res.(*object.Error); ok {
+		return err
+	}
+	if _, ok := res.(*object.CurriedFunction); ok {
+		return object.NewError("test functions can't take parameters")
+	}
+
+	return nil
+}
+
+func describeFailure(err *object.Error) string {
+	message := err.Message
+	if err.Exit {
+		message = fmt.Sprintf("test called exit(%d)", err.ExitCode)
+	}
+
+	if err.Location.Line == 0 {
+		return message
+	}
+
+	return fmt.Sprintf("%s:%d: %s", err.Location.File, err.Location.Line, message)
+}
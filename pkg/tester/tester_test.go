@@ -0,0 +1,97 @@
+package tester
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "lainoa")
+	assert.Nil(t, err)
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.Nil(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+
+	return dir
+}
+
+func TestRun(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"math_test.ln": `let double = fun(x) { x * 2 }
+
+fun test_double() {
+  assert_eq(double(2), 4)
+}
+
+let test_fails = fun() {
+  assert_eq(double(2), 5)
+}
+`,
+		"nested/state_test.ln": `let counter = [0]
+fun test_first() { counter[0] += 1; assert_eq(counter[0], 1) }
+fun test_second() { counter[0] += 1; assert_eq(counter[0], 1) }
+fun helper() { assert(false) }
+`,
+		"math.ln": `fun test_not_a_test_file() { assert(false) }`,
+	})
+	defer os.RemoveAll(dir)
+
+	var out bytes.Buffer
+	summary, err := Run(dir, nil, &out)
+
+	assert.Nil(t, err)
+	assert.Equal(t, Summary{Passed: 3, Failed: 1}, summary)
+
+	mathFile := filepath.Join(dir, "math_test.ln")
+	stateFile := filepath.Join(dir, "nested", "state_test.ln")
+	assert.Equal(t, "ok   "+mathFile+" test_double\n"+
+		"FAIL "+mathFile+" test_fails\n"+
+		"    "+mathFile+":8: assertion failed: expected 5, got 4\n"+
+		"ok   "+stateFile+" test_first\n"+
+		"ok   "+stateFile+" test_second\n", out.String())
+}
+
+func TestRunFilter(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"a_test.ln": `fun test_one() { assert(true) }
+fun test_two() { assert(false) }
+fun test_three() { exit(1) }`,
+	})
+	defer os.RemoveAll(dir)
+
+	var out bytes.Buffer
+	summary, err := Run(dir, regexp.MustCompile("t[wh]"), &out)
+
+	assert.Nil(t, err)
+	assert.Equal(t, Summary{Passed: 0, Failed: 2}, summary)
+	assert.Contains(t, out.String(), "test called exit(1)")
+	assert.NotContains(t, out.String(), "test_one")
+}
+
+func TestRunErrors(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"broken_test.ln": `let = 1`,
+		"params_test.ln": `fun test_params(a) { assert(true) }`,
+	})
+	defer os.RemoveAll(dir)
+
+	var out bytes.Buffer
+	summary, err := Run(dir, nil, &out)
+
+	assert.Nil(t, err)
+	assert.Equal(t, Summary{Passed: 0, Failed: 2}, summary)
+	assert.Contains(t, out.String(), "FAIL "+filepath.Join(dir, "broken_test.ln")+"\n")
+	assert.Contains(t, out.String(), "test functions can't take parameters")
+
+	_, err = Run(filepath.Join(dir, "missing"), nil, &out)
+	assert.NotNil(t, err)
+}
@@ -1,5 +1,7 @@
 package token
 
+import "sort"
+
 type TokenType string
 
 type Metadata struct {
@@ -35,9 +37,19 @@ const (
 	EQ       = "=="
 	NOT_EQ   = "!="
 
+	PIPE    = "|>"
+	COMPOSE = ">>"
+
+	PLUS_ASSIGN     = "+="
+	MINUS_ASSIGN    = "-="
+	ASTERISK_ASSIGN = "*="
+	SLASH_ASSIGN    = "/="
+
 	// Delimiters
 	COMMA     = ","
 	SEMICOLON = ";"
+	COLON     = ":"
+	ELLIPSIS  = "..."
 	LPAREN    = "("
 	RPAREN    = ")"
 	LBRACE    = "{"
@@ -73,3 +85,14 @@ func LookupIdentType(ident string) TokenType {
 	}
 	return IDENT
 }
+
+// Keywords returns the words reserved by the language, sorted.
+func Keywords() []string {
+	words := make([]string, 0, len(keywords))
+	for word := range keywords {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+
+	return words
+}